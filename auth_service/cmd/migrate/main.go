@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"auth.service/internal/migrations"
+	"github.com/joho/godotenv"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+)
+
+// Runs the goose migrations of the auth database, including the Go
+// ones, using the same GOOSE_* settings as the goose CLI:
+//
+//	go run ./cmd/migrate up
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatal("usage: migrate <command> [args]")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("no .env file, using environment")
+	}
+
+	driver := getEnv("GOOSE_DRIVER", "sqlite3")
+	dbstring := getEnv("GOOSE_DBSTRING", "./database/auth.db")
+	if table := os.Getenv("GOOSE_TABLE"); table != "" {
+		goose.SetTableName(table)
+	}
+
+	db, err := sql.Open(driver, dbstring)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := goose.SetDialect(driver); err != nil {
+		log.Fatal(err)
+	}
+
+	goose.SetBaseFS(migrations.FS)
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	if err := goose.RunContext(context.Background(), command, db, ".", args...); err != nil {
+		log.Fatalf("migrate %s: %v", strings.Join(flag.Args(), " "), err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
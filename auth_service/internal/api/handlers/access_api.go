@@ -44,3 +44,28 @@ func (h *AccessServiceHandler) Check(
 		UserId:  userID,
 	}, nil
 }
+
+func (h *AccessServiceHandler) GetPublicKeys(
+	ctx context.Context,
+	req *pb.GetPublicKeysRequest,
+) (*pb.GetPublicKeysResponse, error) {
+	jwks := h.accessService.PublicKeys(ctx)
+
+	resp := &pb.GetPublicKeysResponse{
+		Keys: make([]*pb.JsonWebKey, 0, len(jwks)),
+	}
+	for _, jwk := range jwks {
+		resp.Keys = append(resp.Keys, &pb.JsonWebKey{
+			Kid: jwk.KeyID,
+			Kty: jwk.KeyType,
+			Alg: jwk.Algorithm,
+			Use: jwk.Use,
+			Crv: jwk.Curve,
+			X:   jwk.X,
+			N:   jwk.N,
+			E:   jwk.E,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,55 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+
+	pb "auth.service/api/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSetUserStatus(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	s.makeAdmin(t, s.createUser(t, "admin"))
+
+	req := &pb.SetUserStatusRequest{
+		UserId: aliceID,
+		Status: pb.UserStatus_USER_STATUS_BANNED,
+		Reason: "spam",
+	}
+
+	_, err := s.users.SetUserStatus(s.as(t, "alice"), req)
+	wantCode(t, err, codes.PermissionDenied)
+
+	adminCtx := s.as(t, "admin")
+	user, err := s.users.SetUserStatus(adminCtx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Status != pb.UserStatus_USER_STATUS_BANNED {
+		t.Fatalf("Status = %v, want banned", user.Status)
+	}
+
+	_, err = s.auth.Login(context.Background(), &pb.LoginRequest{
+		Username: "alice",
+		Password: testPassword,
+	})
+	wantCode(t, err, codes.PermissionDenied)
+
+	var info *errdetails.ErrorInfo
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*errdetails.ErrorInfo); ok {
+			info = d
+		}
+	}
+	if info == nil || info.Reason != "ACCOUNT_BANNED" || info.Metadata["reason"] != "spam" {
+		t.Fatalf("unexpected error details %+v", info)
+	}
+
+	_, err = s.users.SetUserStatus(adminCtx, &pb.SetUserStatusRequest{UserId: aliceID})
+	wantCode(t, err, codes.InvalidArgument)
+}
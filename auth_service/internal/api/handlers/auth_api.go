@@ -2,21 +2,37 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"time"
 
 	pb "auth.service/api/proto"
 	"auth.service/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type AuthServiceHandler struct {
 	pb.UnimplementedAuthServiceServer
 	authService service.AuthService
+	mfaService  service.MFAService
 }
 
-func NewAuthServiceHandler(authService service.AuthService) *AuthServiceHandler {
+func NewAuthServiceHandler(
+	authService service.AuthService,
+	mfaService service.MFAService,
+) *AuthServiceHandler {
 	return &AuthServiceHandler{
 		authService: authService,
+		mfaService:  mfaService,
 	}
 }
 
@@ -33,13 +49,38 @@ func (h *AuthServiceHandler) GetAccessToken(
 
 	tokenPair, err := h.authService.RefreshTokens(ctx, req.RefreshToken)
 	if err != nil {
-		return nil, status.Error(
-			codes.Internal, "internal server error",
-		)
+		var inactive *service.AccountInactiveError
+		if errors.As(err, &inactive) {
+			return nil, accountInactiveError(inactive)
+		}
+
+		switch err {
+		case service.ErrInvalidToken:
+			return nil, status.Error(
+				codes.Unauthenticated,
+				"invalid refresh token",
+			)
+		case service.ErrExpiredToken:
+			return nil, status.Error(
+				codes.Unauthenticated,
+				"refresh token expired",
+			)
+		case service.ErrTokenReused:
+			return nil, status.Error(
+				codes.Unauthenticated,
+				"refresh token reused, session revoked",
+			)
+		default:
+			log.Printf("failed to refresh tokens: %v", err)
+			return nil, status.Error(
+				codes.Internal, "internal server error",
+			)
+		}
 	}
 
 	return &pb.AccessTokenResponse{
-		AccessToken: tokenPair.AccessToken,
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
 	}, nil
 }
 
@@ -54,8 +95,23 @@ func (h *AuthServiceHandler) Login(
 		)
 	}
 
-	tokenPair, err := h.authService.Login(ctx, req.Username, req.Password)
+	result, err := h.authService.Login(
+		ctx,
+		req.Username,
+		req.Password,
+		clientInfo(ctx),
+	)
 	if err != nil {
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, throttledError(throttled)
+		}
+
+		var inactive *service.AccountInactiveError
+		if errors.As(err, &inactive) {
+			return nil, accountInactiveError(inactive)
+		}
+
 		switch err {
 		case service.ErrInvalidCredentials:
 			return nil, status.Error(
@@ -63,15 +119,431 @@ func (h *AuthServiceHandler) Login(
 				"invalid credentials",
 			)
 		default:
+			log.Printf("failed to login: %v", err)
 			return nil, status.Error(
 				codes.Internal, "internal server error",
 			)
 		}
 	}
 
+	if result.MFAToken != "" {
+		return &pb.LoginResponse{
+			UserId:      result.UserID,
+			MfaRequired: true,
+			MfaToken:    result.MFAToken,
+		}, nil
+	}
+
+	return &pb.LoginResponse{
+		UserId:       result.UserID,
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	}, nil
+}
+
+func (h *AuthServiceHandler) VerifyMFA(
+	ctx context.Context,
+	req *pb.VerifyMFARequest,
+) (*pb.LoginResponse, error) {
+	if req.MfaToken == "" || req.Code == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"MFA token and code are required",
+		)
+	}
+
+	tokenPair, err := h.authService.VerifyMFA(
+		ctx,
+		req.MfaToken,
+		req.Code,
+		clientInfo(ctx),
+	)
+	if err != nil {
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, throttledError(throttled)
+		}
+
+		var inactive *service.AccountInactiveError
+		if errors.As(err, &inactive) {
+			return nil, accountInactiveError(inactive)
+		}
+
+		switch err {
+		case service.ErrInvalidToken:
+			return nil, status.Error(codes.Unauthenticated, "invalid MFA token")
+		case service.ErrExpiredToken:
+			return nil, status.Error(codes.Unauthenticated, "MFA token expired")
+		case service.ErrInvalidMFACode:
+			return nil, status.Error(codes.Unauthenticated, "invalid code")
+		default:
+			log.Printf("failed to verify MFA: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
 	return &pb.LoginResponse{
 		UserId:       tokenPair.UserID,
 		AccessToken:  tokenPair.AccessToken,
 		RefreshToken: tokenPair.RefreshToken,
 	}, nil
 }
+
+func (h *AuthServiceHandler) Logout(
+	ctx context.Context,
+	req *pb.LogoutRequest,
+) (*emptypb.Empty, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"refresh token is required",
+		)
+	}
+
+	err := h.authService.Logout(ctx, req.RefreshToken, req.AccessToken)
+	if err != nil {
+		log.Printf("failed to logout: %v", err)
+		switch err {
+		case service.ErrSessionNotFound:
+			return nil, status.Error(codes.NotFound, "session not found")
+		default:
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthServiceHandler) LogoutAll(
+	ctx context.Context,
+	req *pb.LogoutAllRequest,
+) (*emptypb.Empty, error) {
+	if req.UserId == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"user ID is required",
+		)
+	}
+
+	if _, err := authorizeUser(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
+	err := h.authService.LogoutAll(ctx, req.UserId)
+	if err != nil {
+		log.Printf("failed to logout all sessions: %v", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthServiceHandler) ListSessions(
+	ctx context.Context,
+	req *pb.ListSessionsRequest,
+) (*pb.ListSessionsResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"user ID is required",
+		)
+	}
+
+	if _, err := authorizeUser(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
+	sessions, err := h.authService.ListSessions(ctx, req.UserId)
+	if err != nil {
+		log.Printf("failed to list sessions: %v", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	resp := &pb.ListSessionsResponse{
+		Sessions: make([]*pb.Session, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &pb.Session{
+			SessionId: session.ID,
+			Device:    session.Device,
+			CreatedAt: timestamppb.New(session.CreatedAt),
+			ExpiresAt: timestamppb.New(session.ExpiresAt),
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *AuthServiceHandler) RevokeSession(
+	ctx context.Context,
+	req *pb.RevokeSessionRequest,
+) (*emptypb.Empty, error) {
+	if req.SessionId == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"session ID is required",
+		)
+	}
+
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admins may revoke any session; others only their own.
+	ownerID := claims.UserID
+	if isAdmin(claims) {
+		ownerID = ""
+	}
+
+	err = h.authService.RevokeSession(ctx, req.SessionId, ownerID)
+	if err != nil {
+		log.Printf("failed to revoke session: %v", err)
+		switch err {
+		case service.ErrSessionNotFound:
+			return nil, status.Error(codes.NotFound, "session not found")
+		default:
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthServiceHandler) EnrollTOTP(
+	ctx context.Context,
+	req *pb.EnrollTOTPRequest,
+) (*pb.EnrollTOTPResponse, error) {
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := h.mfaService.EnrollTOTP(ctx, claims.UserID)
+	if err != nil {
+		switch err {
+		case service.ErrMFAAlreadyEnabled:
+			return nil, status.Error(
+				codes.FailedPrecondition,
+				"two-factor authentication already enabled",
+			)
+		default:
+			log.Printf("failed to enroll TOTP: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &pb.EnrollTOTPResponse{
+		Secret:     enrollment.Secret,
+		OtpauthUri: enrollment.URI,
+	}, nil
+}
+
+func (h *AuthServiceHandler) ConfirmTOTP(
+	ctx context.Context,
+	req *pb.ConfirmTOTPRequest,
+) (*pb.ConfirmTOTPResponse, error) {
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := h.mfaService.ConfirmTOTP(
+		ctx,
+		claims.UserID,
+		req.Code,
+		clientInfo(ctx),
+	)
+	if err != nil {
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, throttledError(throttled)
+		}
+
+		switch err {
+		case service.ErrMFANotEnrolled:
+			return nil, status.Error(
+				codes.FailedPrecondition,
+				"two-factor authentication not enrolled",
+			)
+		case service.ErrMFAAlreadyEnabled:
+			return nil, status.Error(
+				codes.FailedPrecondition,
+				"two-factor authentication already enabled",
+			)
+		case service.ErrInvalidMFACode:
+			return nil, status.Error(codes.InvalidArgument, "invalid code")
+		default:
+			log.Printf("failed to confirm TOTP: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &pb.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (h *AuthServiceHandler) DisableTOTP(
+	ctx context.Context,
+	req *pb.DisableTOTPRequest,
+) (*emptypb.Empty, error) {
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.mfaService.DisableTOTP(ctx, claims.UserID, req.Code, clientInfo(ctx))
+	if err != nil {
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, throttledError(throttled)
+		}
+
+		switch err {
+		case service.ErrMFANotEnabled:
+			return nil, status.Error(
+				codes.FailedPrecondition,
+				"two-factor authentication not enabled",
+			)
+		case service.ErrInvalidMFACode:
+			return nil, status.Error(codes.InvalidArgument, "invalid code")
+		default:
+			log.Printf("failed to disable TOTP: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthServiceHandler) ChangePassword(
+	ctx context.Context,
+	req *pb.ChangePasswordRequest,
+) (*pb.AccessTokenResponse, error) {
+	if req.OldPassword == "" || req.NewPassword == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"old and new passwords are required",
+		)
+	}
+
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenPair, err := h.authService.ChangePassword(
+		ctx,
+		claims.UserID,
+		req.OldPassword,
+		req.NewPassword,
+		clientInfo(ctx),
+	)
+	if err != nil {
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, throttledError(throttled)
+		}
+
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError(invalid)
+		}
+
+		switch err {
+		case service.ErrInvalidCredentials:
+			return nil, status.Error(
+				codes.PermissionDenied,
+				"current password is incorrect",
+			)
+		case service.ErrUserNotFound:
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			log.Printf("failed to change password: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &pb.AccessTokenResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+	}, nil
+}
+
+// throttledError reports a throttled login as ResourceExhausted with a
+// RetryInfo detail telling the client when to try again.
+func throttledError(err *service.ThrottledError) error {
+	st := status.New(codes.ResourceExhausted, "too many login attempts")
+
+	detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(err.RetryAfter),
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// accountInactiveError reports a disabled or banned account as
+// PermissionDenied with an ErrorInfo detail carrying the reason and, for
+// temporary restrictions, when they end.
+func accountInactiveError(err *service.AccountInactiveError) error {
+	st := status.New(codes.PermissionDenied, err.Error())
+
+	info := &errdetails.ErrorInfo{
+		Reason:   "ACCOUNT_" + strings.ToUpper(err.Status),
+		Domain:   "auth.service",
+		Metadata: map[string]string{},
+	}
+	if err.Reason != "" {
+		info.Metadata["reason"] = err.Reason
+	}
+	if err.Until != nil {
+		info.Metadata["until"] = err.Until.UTC().Format(time.RFC3339)
+	}
+
+	detailed, detailErr := st.WithDetails(info)
+	if detailErr != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// clientInfo describes the caller from request metadata and peer info.
+// Clients may name their device with "x-device"; otherwise the user
+// agent is used.
+func clientInfo(ctx context.Context) service.ClientInfo {
+	var info service.ClientInfo
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		info.IP = host
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return info
+	}
+
+	if values := md.Get("x-device"); len(values) > 0 {
+		info.Device = values[0]
+	} else if values := md.Get("user-agent"); len(values) > 0 {
+		info.Device = values[0]
+	}
+
+	return info
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+
+	pb "auth.service/api/proto"
+	"auth.service/internal/api/interceptors"
+	"auth.service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PublicMethods can be called without an access token.
+var PublicMethods = []string{
+	pb.UserService_CreateUser_FullMethodName,
+	pb.UserService_RestoreUser_FullMethodName,
+	pb.UserService_RequestPasswordReset_FullMethodName,
+	pb.UserService_ResetPassword_FullMethodName,
+	pb.AuthService_Login_FullMethodName,
+	pb.AuthService_VerifyMFA_FullMethodName,
+	pb.AuthService_GetAccessToken_FullMethodName,
+	pb.AuthService_Logout_FullMethodName,
+	pb.AccessService_Check_FullMethodName,
+	pb.AccessService_GetPublicKeys_FullMethodName,
+}
+
+// caller returns the claims the auth interceptor stored for this call.
+func caller(ctx context.Context) (*service.TokenClaims, error) {
+	claims, ok := interceptors.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "access token is required")
+	}
+
+	return claims, nil
+}
+
+// authorizeUser lets callers act on their own account, and admins on
+// any account.
+func authorizeUser(ctx context.Context, userID string) (*service.TokenClaims, error) {
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.UserID != userID && !isAdmin(claims) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	return claims, nil
+}
+
+func isAdmin(claims *service.TokenClaims) bool {
+	return claims.Role == service.RoleAdmin
+}
@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+
+	pb "auth.service/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(
+		context.Background(),
+		"authorization", "Bearer "+token,
+	)
+}
+
+func TestProtectedMethodsRequireAToken(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+
+	for name, ctx := range map[string]context.Context{
+		"no token":      context.Background(),
+		"invalid token": withToken("not-a-token"),
+		"wrong scheme": metadata.AppendToOutgoingContext(
+			context.Background(),
+			"authorization", "Basic YWxpY2U6c2VjcmV0",
+		),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.users.GetUser(ctx, &pb.GetUserRequest{UserId: aliceID})
+			wantCode(t, err, codes.Unauthenticated)
+		})
+	}
+}
+
+func TestPublicMethodsIgnoreInvalidTokens(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	_, err := s.users.CreateUser(withToken("not-a-token"), &pb.CreateUserRequest{
+		Username: "alice",
+		Password: testPassword,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUsersActOnlyOnTheirOwnAccount(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	bobID := s.createUser(t, "bob")
+	ctx := s.as(t, "alice")
+
+	if _, err := s.users.GetUser(ctx, &pb.GetUserRequest{UserId: aliceID}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.users.GetUser(ctx, &pb.GetUserRequest{UserId: bobID})
+	wantCode(t, err, codes.PermissionDenied)
+
+	_, err = s.auth.ListSessions(ctx, &pb.ListSessionsRequest{UserId: bobID})
+	wantCode(t, err, codes.PermissionDenied)
+
+	_, err = s.auth.LogoutAll(ctx, &pb.LogoutAllRequest{UserId: bobID})
+	wantCode(t, err, codes.PermissionDenied)
+
+	_, err = s.users.DeleteUser(ctx, &pb.DeleteUserRequest{UserId: bobID})
+	wantCode(t, err, codes.PermissionDenied)
+
+	_, err = s.users.ListUsers(ctx, &pb.ListUsersRequest{})
+	wantCode(t, err, codes.PermissionDenied)
+}
+
+func TestAdminsActOnAnyAccount(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.createUser(t, "alice")
+	bobID := s.createUser(t, "bob")
+	s.makeAdmin(t, s.createUser(t, "admin"))
+	ctx := s.as(t, "admin")
+
+	if _, err := s.users.GetUser(ctx, &pb.GetUserRequest{UserId: bobID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.users.ListUsers(ctx, &pb.ListUsersRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevokeSessionOfAnotherUser(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.createUser(t, "alice")
+	bobID := s.createUser(t, "bob")
+	s.makeAdmin(t, s.createUser(t, "admin"))
+	bobCtx := s.as(t, "bob")
+
+	sessions, err := s.auth.ListSessions(bobCtx, &pb.ListSessionsRequest{UserId: bobID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := sessions.Sessions[0].SessionId
+
+	// Other users' sessions look like missing ones.
+	_, err = s.auth.RevokeSession(s.as(t, "alice"), &pb.RevokeSessionRequest{SessionId: sessionID})
+	wantCode(t, err, codes.NotFound)
+
+	_, err = s.auth.RevokeSession(s.as(t, "admin"), &pb.RevokeSessionRequest{SessionId: sessionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevokedTokensAreRejected(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	ctx := s.as(t, "alice")
+
+	if _, err := s.auth.LogoutAll(ctx, &pb.LogoutAllRequest{UserId: aliceID}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.users.GetUser(ctx, &pb.GetUserRequest{UserId: aliceID})
+	wantCode(t, err, codes.Unauthenticated)
+}
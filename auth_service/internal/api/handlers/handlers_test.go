@@ -0,0 +1,168 @@
+package handlers_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "auth.service/api/proto"
+	"auth.service/internal/api/handlers"
+	"auth.service/internal/api/interceptors"
+	"auth.service/internal/config"
+	"auth.service/internal/keys"
+	"auth.service/internal/notifier"
+	"auth.service/internal/repository/sqlite"
+	"auth.service/internal/service"
+	"auth.service/internal/testutil"
+	"github.com/jmoiron/sqlx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testPassword = "Tr1cky-Passw0rd"
+
+// testServer serves the handlers the way app.Run does, over an
+// in-memory connection.
+type testServer struct {
+	db    *sqlx.DB
+	users pb.UserServiceClient
+	auth  pb.AuthServiceClient
+}
+
+func newTestServer(t *testing.T, vars map[string]string) *testServer {
+	t.Helper()
+
+	testutil.LoadEnv(t, vars)
+	db := testutil.OpenDB(t)
+
+	userRepo := sqlite.NewUserRepository(db)
+	sessionRepo := sqlite.NewSessionRepository(db)
+	mfaRepo := sqlite.NewMFARepository(db)
+
+	revocations := service.NewRevocationService(sqlite.NewRevocationRepository(db))
+	if err := revocations.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	hasher, err := service.NewPasswordHasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keySet, err := keys.Load(config.Env.JWTSigningKeyPath, config.Env.JWTPublicKeyPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limiter := service.NewLoginLimiter(sqlite.NewLoginAttemptRepository(db))
+	mfaService := service.NewMFAService(mfaRepo, userRepo, limiter)
+	userService := service.NewUserService(
+		userRepo,
+		sessionRepo,
+		sqlite.NewPasswordResetRepository(db),
+		revocations,
+		hasher,
+		notifier.NewLogNotifier(),
+	)
+	authService := service.NewAuthService(
+		userRepo,
+		sessionRepo,
+		mfaRepo,
+		revocations,
+		limiter,
+		hasher,
+		mfaService,
+		userService,
+		keySet,
+		nil,
+		0,
+		0,
+	)
+	profileService := service.NewProfileService(sqlite.NewProfileRepository(db))
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(interceptors.Auth(
+		authService,
+		handlers.PublicMethods...,
+	)))
+	pb.RegisterUserServiceServer(server, handlers.NewUserServiceHandler(
+		userService,
+		authService,
+		profileService,
+	))
+	pb.RegisterAuthServiceServer(server, handlers.NewAuthServiceHandler(authService, mfaService))
+
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{
+		db:    db,
+		users: pb.NewUserServiceClient(conn),
+		auth:  pb.NewAuthServiceClient(conn),
+	}
+}
+
+func (s *testServer) createUser(t *testing.T, username string) string {
+	t.Helper()
+
+	resp, err := s.users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Username: username,
+		Password: testPassword,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser(%q): %v", username, err)
+	}
+
+	return resp.UserId
+}
+
+func (s *testServer) makeAdmin(t *testing.T, userID string) {
+	t.Helper()
+
+	_, err := s.db.Exec(`UPDATE users SET role = ? WHERE id = ?`, service.RoleAdmin, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// as returns a context carrying an access token of the user.
+func (s *testServer) as(t *testing.T, username string) context.Context {
+	t.Helper()
+
+	resp, err := s.auth.Login(context.Background(), &pb.LoginRequest{
+		Username: username,
+		Password: testPassword,
+	})
+	if err != nil {
+		t.Fatalf("Login(%q): %v", username, err)
+	}
+
+	return metadata.AppendToOutgoingContext(
+		context.Background(),
+		"authorization", "Bearer "+resp.AccessToken,
+	)
+}
+
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+
+	if got := status.Code(err); got != want {
+		t.Fatalf("got %v (%v), want %v", got, err, want)
+	}
+}
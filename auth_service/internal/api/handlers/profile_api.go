@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	pb "auth.service/api/proto"
+	"auth.service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// GetProfile is open to every authenticated user, since profiles are
+// what other chat members see.
+func (h *UserServiceHandler) GetProfile(
+	ctx context.Context,
+	req *pb.GetProfileRequest,
+) (*pb.Profile, error) {
+	if req.UserId == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"user ID is required",
+		)
+	}
+
+	if _, err := caller(ctx); err != nil {
+		return nil, err
+	}
+
+	profile, err := h.profileService.GetProfile(ctx, req.UserId)
+	if err != nil {
+		switch err {
+		case service.ErrUserNotFound:
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			log.Printf("failed to get profile: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return toProtoProfile(profile), nil
+}
+
+func (h *UserServiceHandler) UpdateProfile(
+	ctx context.Context,
+	req *pb.UpdateProfileRequest,
+) (*pb.Profile, error) {
+	if req.UserId == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"user ID is required",
+		)
+	}
+
+	if _, err := authorizeUser(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
+	update := service.ProfileUpdate{
+		DisplayName:        stringValue(req.DisplayName),
+		Bio:                stringValue(req.Bio),
+		AvatarAttachmentID: stringValue(req.AvatarAttachmentId),
+		StatusText:         stringValue(req.StatusText),
+	}
+
+	profile, err := h.profileService.UpdateProfile(ctx, req.UserId, update)
+	if err != nil {
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError(invalid)
+		}
+
+		switch err {
+		case service.ErrUserNotFound:
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			log.Printf("failed to update profile: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return toProtoProfile(profile), nil
+}
+
+// GetUsers resolves many user IDs in one call, e.g. to show the
+// authors of a page of chat messages.
+func (h *UserServiceHandler) GetUsers(
+	ctx context.Context,
+	req *pb.GetUsersRequest,
+) (*pb.GetUsersResponse, error) {
+	if _, err := caller(ctx); err != nil {
+		return nil, err
+	}
+
+	profiles, err := h.profileService.GetProfiles(ctx, req.UserIds)
+	if err != nil {
+		switch err {
+		case service.ErrTooManyUserIDs:
+			return nil, status.Errorf(
+				codes.InvalidArgument,
+				"at most %d user IDs can be requested at once",
+				service.MaxBatchUserIDs,
+			)
+		default:
+			log.Printf("failed to get users: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	resp := &pb.GetUsersResponse{
+		Users: make([]*pb.Profile, 0, len(profiles)),
+	}
+	for _, profile := range profiles {
+		resp.Users = append(resp.Users, toProtoProfile(profile))
+	}
+
+	return resp, nil
+}
+
+func (h *UserServiceHandler) SearchUsers(
+	ctx context.Context,
+	req *pb.SearchUsersRequest,
+) (*pb.SearchUsersResponse, error) {
+	if _, err := caller(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Limit < 0 {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"limit must not be negative",
+		)
+	}
+
+	page, err := h.profileService.SearchUsers(ctx, service.SearchUsersParams{
+		Query:     req.Query,
+		Limit:     int(req.Limit),
+		PageToken: req.PageToken,
+	})
+	if err != nil {
+		switch err {
+		case service.ErrInvalidQuery:
+			return nil, status.Error(
+				codes.InvalidArgument,
+				"query must be between 1 and 64 characters long",
+			)
+		case service.ErrInvalidPageToken:
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		default:
+			log.Printf("failed to search users: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	resp := &pb.SearchUsersResponse{
+		Users:         make([]*pb.Profile, 0, len(page.Profiles)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, profile := range page.Profiles {
+		resp.Users = append(resp.Users, toProtoProfile(profile))
+	}
+
+	return resp, nil
+}
+
+func toProtoProfile(profile *service.Profile) *pb.Profile {
+	resp := &pb.Profile{
+		UserId:             profile.UserID,
+		Username:           profile.Username,
+		DisplayName:        profile.DisplayName,
+		Bio:                profile.Bio,
+		AvatarAttachmentId: profile.AvatarAttachmentID,
+		StatusText:         profile.StatusText,
+	}
+	if profile.UpdatedAt != nil {
+		resp.UpdatedAt = timestamppb.New(*profile.UpdatedAt)
+	}
+
+	return resp
+}
+
+func stringValue(value *wrapperspb.StringValue) *string {
+	if value == nil {
+		return nil
+	}
+
+	return &value.Value
+}
@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"fmt"
+	"testing"
+
+	pb "auth.service/api/proto"
+	"auth.service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestUpdateProfileAuthorization(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	s.createUser(t, "bob")
+	adminID := s.createUser(t, "admin")
+	s.makeAdmin(t, adminID)
+
+	update := func(username string) (*pb.Profile, error) {
+		return s.users.UpdateProfile(s.as(t, username), &pb.UpdateProfileRequest{
+			UserId:      aliceID,
+			DisplayName: wrapperspb.String("Alice " + username),
+		})
+	}
+
+	_, err := update("bob")
+	wantCode(t, err, codes.PermissionDenied)
+
+	for _, username := range []string{"alice", "admin"} {
+		if _, err := update(username); err != nil {
+			t.Fatalf("%s updating alice's profile: %v", username, err)
+		}
+	}
+
+	// Everyone signed in can read profiles.
+	profile, err := s.users.GetProfile(s.as(t, "bob"), &pb.GetProfileRequest{UserId: aliceID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.DisplayName != "Alice admin" {
+		t.Fatalf("DisplayName = %q, want %q", profile.DisplayName, "Alice admin")
+	}
+}
+
+func TestUpdateProfileInvalid(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+
+	_, err := s.users.UpdateProfile(s.as(t, "alice"), &pb.UpdateProfileRequest{
+		UserId:     aliceID,
+		StatusText: wrapperspb.String("away\x07"),
+	})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestGetUsersLimit(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.createUser(t, "alice")
+
+	ids := make([]string, service.MaxBatchUserIDs+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("id-%d", i)
+	}
+
+	_, err := s.users.GetUsers(s.as(t, "alice"), &pb.GetUsersRequest{UserIds: ids})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestSearchUsers(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.createUser(t, "alice")
+	s.createUser(t, "sally")
+	ctx := s.as(t, "alice")
+
+	resp, err := s.users.SearchUsers(ctx, &pb.SearchUsersRequest{Query: "al", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Users) != 1 || resp.Users[0].Username != "alice" || resp.NextPageToken == "" {
+		t.Fatalf("first page = %v, want alice and a next page token", resp)
+	}
+
+	for _, req := range []*pb.SearchUsersRequest{
+		{Query: ""},
+		{Query: "al", Limit: -1},
+		{Query: "sa", PageToken: resp.NextPageToken},
+	} {
+		_, err := s.users.SearchUsers(ctx, req)
+		wantCode(t, err, codes.InvalidArgument)
+	}
+}
@@ -2,22 +2,35 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
 
 	pb "auth.service/api/proto"
 	"auth.service/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type UserServiceHandler struct {
 	pb.UnimplementedUserServiceServer
-	userService service.UserService
+	userService    service.UserService
+	authService    service.AuthService
+	profileService service.ProfileService
 }
 
-func NewUserServiceHandler(userService service.UserService) *UserServiceHandler {
+func NewUserServiceHandler(
+	userService service.UserService,
+	authService service.AuthService,
+	profileService service.ProfileService,
+) *UserServiceHandler {
 	return &UserServiceHandler{
-		userService: userService,
+		userService:    userService,
+		authService:    authService,
+		profileService: profileService,
 	}
 }
 
@@ -32,8 +45,13 @@ func (h *UserServiceHandler) CreateUser(
 		)
 	}
 
-	userID, err := h.userService.CreateUser(ctx, req.Username, req.Password)
+	user, err := h.userService.CreateUser(ctx, req.Username, req.Password)
 	if err != nil {
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError(invalid)
+		}
+
 		log.Printf("failed to create user: %v", err)
 		switch err {
 		case service.ErrUserAlreadyExists:
@@ -44,8 +62,8 @@ func (h *UserServiceHandler) CreateUser(
 	}
 
 	return &pb.UserResponse{
-		UserId:   userID,
-		Username: req.Username,
+		UserId:   user.ID,
+		Username: user.Username,
 	}, nil
 }
 
@@ -60,6 +78,10 @@ func (h *UserServiceHandler) GetUser(
 		)
 	}
 
+	if _, err := authorizeUser(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
 	user, err := h.userService.GetUserByID(ctx, req.UserId)
 	if err != nil {
 		log.Printf("failed to create user: %v", err)
@@ -89,6 +111,11 @@ func (h *UserServiceHandler) UpdateUser(
 	}
 
 	userID := req.UserId.Value
+	claims, err := authorizeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	var username, password string
 
 	if req.Username != nil {
@@ -101,12 +128,39 @@ func (h *UserServiceHandler) UpdateUser(
 	if username == "" && password == "" {
 		return nil, status.Error(
 			codes.InvalidArgument,
-			"username and password are required",
+			"username or password is required",
 		)
 	}
 
-	err := h.userService.UpdateUser(ctx, userID, username, password)
+	// Owners have to prove they know the current password, which only
+	// ChangePassword does. Admins may reset other users' passwords.
+	if password != "" && (!isAdmin(claims) || claims.UserID == userID) {
+		return nil, status.Error(
+			codes.PermissionDenied,
+			"use ChangePassword to change your own password",
+		)
+	}
+
+	// Each field is a separate write, so a request setting both could
+	// fail halfway, after the username had already changed.
+	if username != "" && password != "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"username and password must be changed in separate requests",
+		)
+	}
+
+	if username != "" {
+		err = h.userService.UpdateUser(ctx, userID, username)
+	} else {
+		err = h.userService.SetPassword(ctx, userID, password)
+	}
 	if err != nil {
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError(invalid)
+		}
+
 		log.Printf("failed to update user: %v", err)
 		switch err {
 		case service.ErrUserAlreadyExists:
@@ -140,6 +194,10 @@ func (h *UserServiceHandler) DeleteUser(
 		)
 	}
 
+	if _, err := authorizeUser(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
 	user, err := h.userService.GetUserByID(ctx, req.UserId)
 	if err != nil {
 		log.Printf("failed to create user: %v", err)
@@ -154,6 +212,85 @@ func (h *UserServiceHandler) DeleteUser(
 	err = h.userService.DeleteUser(ctx, req.UserId)
 	if err != nil {
 		log.Printf("failed to delete user: %v", err)
+		switch err {
+		case service.ErrUserNotFound:
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &pb.UserResponse{
+		UserId:   user.ID,
+		Username: user.Username,
+	}, nil
+}
+
+func (h *UserServiceHandler) RestoreUser(
+	ctx context.Context,
+	req *pb.RestoreUserRequest,
+) (*pb.UserResponse, error) {
+	userID := req.UserId
+
+	if userID != "" {
+		claims, err := caller(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdmin(claims) {
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		if err := h.userService.RestoreUserByID(ctx, userID); err != nil {
+			log.Printf("failed to restore user: %v", err)
+			switch err {
+			case service.ErrUserNotFound:
+				return nil, status.Error(
+					codes.NotFound,
+					"no restorable user with this ID",
+				)
+			default:
+				return nil, status.Error(codes.Internal, "internal server error")
+			}
+		}
+	} else {
+		if req.Username == "" || req.Password == "" {
+			return nil, status.Error(
+				codes.InvalidArgument,
+				"user ID or username and password are required",
+			)
+		}
+
+		var err error
+		userID, err = h.authService.RestoreUser(
+			ctx,
+			req.Username,
+			req.Password,
+			clientInfo(ctx),
+		)
+		if err != nil {
+			var throttled *service.ThrottledError
+			if errors.As(err, &throttled) {
+				return nil, throttledError(throttled)
+			}
+
+			switch err {
+			case service.ErrInvalidCredentials:
+				return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+			case service.ErrRestoreWindowExpired:
+				return nil, status.Error(
+					codes.FailedPrecondition,
+					"account can no longer be restored",
+				)
+			default:
+				log.Printf("failed to restore user: %v", err)
+				return nil, status.Error(codes.Internal, "internal server error")
+			}
+		}
+	}
+
+	user, err := h.userService.GetUserByID(ctx, userID)
+	if err != nil {
 		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
@@ -162,3 +299,279 @@ func (h *UserServiceHandler) DeleteUser(
 		Username: user.Username,
 	}, nil
 }
+
+// GetUsersByIDs and GetUsersByUsernames are open to every authenticated
+// caller, like GetProfile, and only return public fields.
+func (h *UserServiceHandler) GetUsersByIDs(
+	ctx context.Context,
+	req *pb.GetUsersByIDsRequest,
+) (*pb.GetUsersByIDsResponse, error) {
+	if _, err := caller(ctx); err != nil {
+		return nil, err
+	}
+
+	batch, err := h.userService.GetUsersByIDs(ctx, req.UserIds)
+	if err != nil {
+		return nil, batchLookupError(err)
+	}
+
+	return &pb.GetUsersByIDsResponse{
+		Users:          toUserResponses(batch.Users),
+		MissingUserIds: batch.Missing,
+	}, nil
+}
+
+func (h *UserServiceHandler) GetUsersByUsernames(
+	ctx context.Context,
+	req *pb.GetUsersByUsernamesRequest,
+) (*pb.GetUsersByUsernamesResponse, error) {
+	if _, err := caller(ctx); err != nil {
+		return nil, err
+	}
+
+	batch, err := h.userService.GetUsersByUsernames(ctx, req.Usernames)
+	if err != nil {
+		return nil, batchLookupError(err)
+	}
+
+	return &pb.GetUsersByUsernamesResponse{
+		Users:            toUserResponses(batch.Users),
+		MissingUsernames: batch.Missing,
+	}, nil
+}
+
+func (h *UserServiceHandler) ListUsers(
+	ctx context.Context,
+	req *pb.ListUsersRequest,
+) (*pb.ListUsersResponse, error) {
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(claims) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	if req.PageSize < 0 {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"page size must not be negative",
+		)
+	}
+
+	page, err := h.userService.ListUsers(ctx, service.ListUsersParams{
+		PageToken:      req.PageToken,
+		PageSize:       int(req.PageSize),
+		UsernamePrefix: req.UsernamePrefix,
+		OrderBy:        req.OrderBy,
+	})
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPageToken:
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		case service.ErrInvalidOrderBy:
+			return nil, status.Error(
+				codes.InvalidArgument,
+				`order_by must be "username" or "created_at", optionally followed by " desc"`,
+			)
+		default:
+			log.Printf("failed to list users: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	resp := &pb.ListUsersResponse{
+		Users:         make([]*pb.User, 0, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, user := range page.Users {
+		resp.Users = append(resp.Users, toProtoUser(user))
+	}
+
+	return resp, nil
+}
+
+func (h *UserServiceHandler) SetUserStatus(
+	ctx context.Context,
+	req *pb.SetUserStatusRequest,
+) (*pb.User, error) {
+	claims, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(claims) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	if req.UserId == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"user ID is required",
+		)
+	}
+
+	userStatus, ok := userStatuses[req.Status]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "status is required")
+	}
+
+	var until *time.Time
+	if req.Until != nil {
+		t := req.Until.AsTime()
+		until = &t
+	}
+
+	err = h.userService.SetUserStatus(ctx, req.UserId, userStatus, req.Reason, until)
+	if err != nil {
+		log.Printf("failed to set user status: %v", err)
+		switch err {
+		case service.ErrUserNotFound:
+			return nil, status.Error(codes.NotFound, "user not found")
+		case service.ErrInvalidStatus:
+			return nil, status.Error(codes.InvalidArgument, "invalid status")
+		default:
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	user, err := h.userService.GetUserByID(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return toProtoUser(user), nil
+}
+
+func (h *UserServiceHandler) RequestPasswordReset(
+	ctx context.Context,
+	req *pb.RequestPasswordResetRequest,
+) (*emptypb.Empty, error) {
+	if req.Username == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"username is required",
+		)
+	}
+
+	err := h.authService.RequestPasswordReset(ctx, req.Username, clientInfo(ctx))
+	if err != nil {
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, throttledError(throttled)
+		}
+
+		log.Printf("failed to request password reset: %v", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserServiceHandler) ResetPassword(
+	ctx context.Context,
+	req *pb.ResetPasswordRequest,
+) (*emptypb.Empty, error) {
+	if req.Token == "" || req.NewPassword == "" {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"token and new password are required",
+		)
+	}
+
+	err := h.userService.ResetPassword(ctx, req.Token, req.NewPassword)
+	if err != nil {
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			return nil, validationError(invalid)
+		}
+
+		switch err {
+		case service.ErrInvalidToken:
+			return nil, status.Error(codes.Unauthenticated, "invalid reset token")
+		case service.ErrExpiredToken:
+			return nil, status.Error(codes.Unauthenticated, "reset token expired")
+		default:
+			log.Printf("failed to reset password: %v", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func batchLookupError(err error) error {
+	switch err {
+	case service.ErrTooManyUserIDs:
+		return status.Errorf(
+			codes.InvalidArgument,
+			"at most %d users can be requested at once",
+			service.MaxBatchUserIDs,
+		)
+	default:
+		log.Printf("failed to look up users: %v", err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+func toUserResponses(users map[string]*service.User) map[string]*pb.UserResponse {
+	resp := make(map[string]*pb.UserResponse, len(users))
+	for id, user := range users {
+		resp[id] = &pb.UserResponse{
+			UserId:   user.ID,
+			Username: user.Username,
+		}
+	}
+
+	return resp
+}
+
+var userStatuses = map[pb.UserStatus]string{
+	pb.UserStatus_USER_STATUS_ACTIVE:   service.StatusActive,
+	pb.UserStatus_USER_STATUS_DISABLED: service.StatusDisabled,
+	pb.UserStatus_USER_STATUS_BANNED:   service.StatusBanned,
+}
+
+func toProtoUser(user *service.User) *pb.User {
+	resp := &pb.User{
+		UserId:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		CreatedAt:    timestamppb.New(user.CreatedAt),
+		UpdatedAt:    timestamppb.New(user.UpdatedAt),
+		StatusReason: user.StatusReason,
+	}
+
+	for protoStatus, userStatus := range userStatuses {
+		if userStatus == user.Status {
+			resp.Status = protoStatus
+		}
+	}
+	if user.StatusUntil != nil {
+		resp.StatusUntil = timestamppb.New(*user.StatusUntil)
+	}
+
+	return resp
+}
+
+// validationError reports policy violations as InvalidArgument with a
+// BadRequest detail, so clients can show every problem at once.
+func validationError(err *service.ValidationError) error {
+	st := status.New(codes.InvalidArgument, "invalid request")
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(err.Violations))
+	for _, v := range err.Violations {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	detailed, detailErr := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: violations,
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
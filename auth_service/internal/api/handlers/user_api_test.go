@@ -0,0 +1,184 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+
+	pb "auth.service/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCreateUserReturnsCanonicalUsername(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	resp, err := s.users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Username: " Ａlice ",
+		Password: testPassword,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Username != "Alice" {
+		t.Fatalf("Username = %q, want the name to log in with, %q", resp.Username, "Alice")
+	}
+
+	s.as(t, resp.Username)
+}
+
+func TestUpdateUserRename(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	s.createUser(t, "bob")
+	ctx := s.as(t, "alice")
+
+	resp, err := s.users.UpdateUser(ctx, &pb.UpdateUserRequest{
+		UserId:   wrapperspb.String(aliceID),
+		Username: wrapperspb.String("alicia"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Username != "alicia" {
+		t.Fatalf("Username = %q, want alicia", resp.Username)
+	}
+
+	_, err = s.users.UpdateUser(ctx, &pb.UpdateUserRequest{
+		UserId:   wrapperspb.String(aliceID),
+		Username: wrapperspb.String("BOB"),
+	})
+	wantCode(t, err, codes.AlreadyExists)
+}
+
+func TestUpdateUserPasswordIsAdminOnly(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	adminID := s.createUser(t, "admin")
+	s.makeAdmin(t, adminID)
+
+	const newPassword = "An0ther-Passw0rd"
+
+	// Owners, admins included, must go through ChangePassword.
+	for _, tt := range []struct {
+		username string
+		userID   string
+	}{
+		{"alice", aliceID},
+		{"admin", adminID},
+	} {
+		_, err := s.users.UpdateUser(s.as(t, tt.username), &pb.UpdateUserRequest{
+			UserId:   wrapperspb.String(tt.userID),
+			Password: wrapperspb.String(newPassword),
+		})
+		wantCode(t, err, codes.PermissionDenied)
+	}
+
+	// The rejected request changed nothing, not even the username.
+	_, err := s.users.UpdateUser(s.as(t, "alice"), &pb.UpdateUserRequest{
+		UserId:   wrapperspb.String(aliceID),
+		Username: wrapperspb.String("alicia"),
+		Password: wrapperspb.String(newPassword),
+	})
+	wantCode(t, err, codes.PermissionDenied)
+	s.as(t, "alice")
+
+	_, err = s.users.UpdateUser(s.as(t, "admin"), &pb.UpdateUserRequest{
+		UserId:   wrapperspb.String(aliceID),
+		Password: wrapperspb.String(newPassword),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.auth.Login(context.Background(), &pb.LoginRequest{
+		Username: "alice",
+		Password: testPassword,
+	})
+	wantCode(t, err, codes.Unauthenticated)
+
+	_, err = s.auth.Login(context.Background(), &pb.LoginRequest{
+		Username: "alice",
+		Password: newPassword,
+	})
+	if err != nil {
+		t.Fatalf("Login with the password set by an admin: %v", err)
+	}
+}
+
+func TestUpdateUserRejectsUsernameAndPasswordTogether(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	adminID := s.createUser(t, "admin")
+	s.makeAdmin(t, adminID)
+
+	// The password breaks the policy; the username must not change
+	// either.
+	for _, password := range []string{"short", "An0ther-Passw0rd"} {
+		_, err := s.users.UpdateUser(s.as(t, "admin"), &pb.UpdateUserRequest{
+			UserId:   wrapperspb.String(aliceID),
+			Username: wrapperspb.String("alicia"),
+			Password: wrapperspb.String(password),
+		})
+		wantCode(t, err, codes.InvalidArgument)
+	}
+
+	s.as(t, "alice")
+}
+
+func TestUpdateUserOfAnotherUser(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+	s.createUser(t, "bob")
+
+	_, err := s.users.UpdateUser(s.as(t, "bob"), &pb.UpdateUserRequest{
+		UserId:   wrapperspb.String(aliceID),
+		Username: wrapperspb.String("mallory"),
+	})
+	wantCode(t, err, codes.PermissionDenied)
+}
+
+func TestRequestPasswordResetIsThrottled(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	for i := range 4 {
+		_, err := s.users.RequestPasswordReset(context.Background(), &pb.RequestPasswordResetRequest{
+			Username: "alice",
+		})
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+
+	_, err := s.users.RequestPasswordReset(context.Background(), &pb.RequestPasswordResetRequest{
+		Username: "alice",
+	})
+	wantCode(t, err, codes.ResourceExhausted)
+}
+
+func TestGetUsersByIDs(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	aliceID := s.createUser(t, "alice")
+
+	_, err := s.users.GetUsersByIDs(context.Background(), &pb.GetUsersByIDsRequest{
+		UserIds: []string{aliceID},
+	})
+	wantCode(t, err, codes.Unauthenticated)
+
+	resp, err := s.users.GetUsersByIDs(s.as(t, "alice"), &pb.GetUsersByIDsRequest{
+		UserIds: []string{aliceID, "missing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user := resp.Users[aliceID]; user == nil || user.Username != "alice" {
+		t.Fatalf("Users = %v, want alice keyed by ID", resp.Users)
+	}
+	if len(resp.MissingUserIds) != 1 || resp.MissingUserIds[0] != "missing" {
+		t.Fatalf("MissingUserIds = %q, want [missing]", resp.MissingUserIds)
+	}
+}
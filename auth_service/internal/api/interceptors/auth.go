@@ -0,0 +1,83 @@
+package interceptors
+
+import (
+	"context"
+	"strings"
+
+	"auth.service/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type claimsKey struct{}
+
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, accessToken string) (*service.TokenClaims, error)
+}
+
+// Auth authenticates unary calls with the access token sent as
+// "authorization: Bearer <token>" metadata and stores its claims in the
+// context. Every method not listed in publicMethods requires a valid
+// token; public methods get claims only if a valid token is present.
+func Auth(
+	validator TokenValidator,
+	publicMethods ...string,
+) grpc.UnaryServerInterceptor {
+	public := make(map[string]bool, len(publicMethods))
+	for _, method := range publicMethods {
+		public[method] = true
+	}
+
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		accessToken := bearerToken(ctx)
+
+		if accessToken == "" {
+			if public[info.FullMethod] {
+				return handler(ctx, req)
+			}
+			return nil, status.Error(codes.Unauthenticated, "access token is required")
+		}
+
+		claims, err := validator.ValidateToken(ctx, accessToken)
+		if err != nil {
+			if public[info.FullMethod] {
+				return handler(ctx, req)
+			}
+			return nil, status.Error(codes.Unauthenticated, "invalid access token")
+		}
+
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller.
+func ClaimsFromContext(ctx context.Context) (*service.TokenClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*service.TokenClaims)
+	return claims, ok
+}
+
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,78 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"auth.service/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeValidator struct{}
+
+func (fakeValidator) ValidateToken(
+	ctx context.Context,
+	accessToken string,
+) (*service.TokenClaims, error) {
+	if accessToken != "good" {
+		return nil, errors.New("invalid token")
+	}
+	return &service.TokenClaims{UserID: "user-1"}, nil
+}
+
+// call runs the interceptor for method with the given authorization
+// header and returns the claims the handler saw.
+func call(t *testing.T, method, authorization string) (*service.TokenClaims, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	if authorization != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+	}
+
+	var claims *service.TokenClaims
+	handler := func(ctx context.Context, req any) (any, error) {
+		claims, _ = ClaimsFromContext(ctx)
+		return nil, nil
+	}
+
+	interceptor := Auth(fakeValidator{}, "/test/Public")
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+
+	return claims, err
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantCode      codes.Code
+		wantClaims    bool
+	}{
+		{"protected with token", "/test/Private", "Bearer good", codes.OK, true},
+		{"scheme is case-insensitive", "/test/Private", "bearer good", codes.OK, true},
+		{"protected without token", "/test/Private", "", codes.Unauthenticated, false},
+		{"protected with invalid token", "/test/Private", "Bearer bad", codes.Unauthenticated, false},
+		{"protected with other scheme", "/test/Private", "Basic good", codes.Unauthenticated, false},
+		{"public without token", "/test/Public", "", codes.OK, false},
+		{"public with invalid token", "/test/Public", "Bearer bad", codes.OK, false},
+		{"public with token", "/test/Public", "Bearer good", codes.OK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := call(t, tt.method, tt.authorization)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("code = %v, want %v", got, tt.wantCode)
+			}
+			if (claims != nil) != tt.wantClaims {
+				t.Fatalf("claims = %+v, want present: %v", claims, tt.wantClaims)
+			}
+		})
+	}
+}
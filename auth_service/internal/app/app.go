@@ -12,7 +12,10 @@ import (
 
 	pb "auth.service/api/proto"
 	"auth.service/internal/api/handlers"
+	"auth.service/internal/api/interceptors"
 	"auth.service/internal/config"
+	"auth.service/internal/keys"
+	"auth.service/internal/notifier"
 	"auth.service/internal/repository"
 	"auth.service/internal/repository/sqlite"
 	"auth.service/internal/service"
@@ -22,10 +25,15 @@ import (
 )
 
 type App struct {
-	userRepo    repository.UserRepository
-	sessionRepo repository.SessionRepository
-	grpcServer  *grpc.Server
-	port        string
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	revocationRepo repository.RevocationRepository
+	attemptRepo    repository.LoginAttemptRepository
+	mfaRepo        repository.MFARepository
+	resetRepo      repository.PasswordResetRepository
+	profileRepo    repository.ProfileRepository
+	grpcServer     *grpc.Server
+	port           string
 }
 
 func NewApp(
@@ -36,10 +44,20 @@ func NewApp(
 	case "sqlite3":
 		userRepo := sqlite.NewUserRepository(db)
 		sessionRepo := sqlite.NewSessionRepository(db)
+		revocationRepo := sqlite.NewRevocationRepository(db)
+		attemptRepo := sqlite.NewLoginAttemptRepository(db)
+		mfaRepo := sqlite.NewMFARepository(db)
+		resetRepo := sqlite.NewPasswordResetRepository(db)
+		profileRepo := sqlite.NewProfileRepository(db)
 		return &App{
-			userRepo:    userRepo,
-			sessionRepo: sessionRepo,
-			port:        config.Env.GRPCPort,
+			userRepo:       userRepo,
+			sessionRepo:    sessionRepo,
+			revocationRepo: revocationRepo,
+			attemptRepo:    attemptRepo,
+			mfaRepo:        mfaRepo,
+			resetRepo:      resetRepo,
+			profileRepo:    profileRepo,
+			port:           config.Env.GRPCPort,
 		}, nil
 	default:
 		return nil, fmt.Errorf(
@@ -53,21 +71,77 @@ func (a *App) Run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	userService := service.NewUserService(a.userRepo)
+	revocationService := service.NewRevocationService(a.revocationRepo)
+	if err := revocationService.Load(ctx); err != nil {
+		return err
+	}
+
+	keySet, err := keys.Load(
+		config.Env.JWTSigningKeyPath,
+		config.Env.JWTPublicKeyPaths,
+	)
+	if err != nil {
+		return err
+	}
+
+	hasher, err := service.NewPasswordHasher()
+	if err != nil {
+		return err
+	}
+
+	userNotifier, err := notifier.New(
+		config.Env.Notifier,
+		config.Env.NotifierFilePath,
+	)
+	if err != nil {
+		return err
+	}
+
+	userService := service.NewUserService(
+		a.userRepo,
+		a.sessionRepo,
+		a.resetRepo,
+		revocationService,
+		hasher,
+		userNotifier,
+	)
+	loginLimiter := service.NewLoginLimiter(a.attemptRepo)
+	mfaService := service.NewMFAService(a.mfaRepo, a.userRepo, loginLimiter)
 	authService := service.NewAuthService(
 		a.userRepo,
 		a.sessionRepo,
+		a.mfaRepo,
+		revocationService,
+		loginLimiter,
+		hasher,
+		mfaService,
+		userService,
+		keySet,
 		nil,
 		time.Duration(0),
 		time.Duration(0),
 	)
-	accessService := service.NewAccessService(authService)
+	accessService := service.NewAccessService(authService, a.userRepo, keySet)
+	profileService := service.NewProfileService(a.profileRepo)
+
+	go userService.RunPurgeJob(ctx)
+	go authService.RunSessionCleanupJob(ctx)
+	go loginLimiter.RunPruneJob(ctx)
 
-	userHandler := handlers.NewUserServiceHandler(userService)
-	authHandler := handlers.NewAuthServiceHandler(authService)
+	userHandler := handlers.NewUserServiceHandler(
+		userService,
+		authService,
+		profileService,
+	)
+	authHandler := handlers.NewAuthServiceHandler(authService, mfaService)
 	accessHandler := handlers.NewAccessServiceHandler(accessService)
 
-	a.grpcServer = grpc.NewServer()
+	a.grpcServer = grpc.NewServer(
+		grpc.UnaryInterceptor(interceptors.Auth(
+			authService,
+			handlers.PublicMethods...,
+		)),
+	)
 
 	pb.RegisterUserServiceServer(a.grpcServer, userHandler)
 	pb.RegisterAuthServiceServer(a.grpcServer, authHandler)
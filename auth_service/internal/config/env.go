@@ -2,18 +2,50 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type env struct {
-	SqlitePath      string
-	GRPCPort        string
-	JWTSecret       string
-	AccessTokenTTL  string
-	RefreshTokenTTL string
+	SqlitePath        string
+	GRPCPort          string
+	JWTSecret         string
+	JWTSigningKeyPath string
+	JWTPublicKeyPaths []string
+	AccessTokenTTL    string
+	RefreshTokenTTL   string
+
+	LoginFreeAttempts    string
+	LoginLockoutAttempts string
+	LoginLockoutDuration string
+
+	PasswordMinLength      string
+	PasswordMinClasses     string
+	PasswordForbidUsername string
+
+	PasswordHashAlgorithm string
+	BcryptCost            string
+	Argon2Time            string
+	Argon2Memory          string
+	Argon2Threads         string
+
+	TOTPIssuer      string
+	MFAChallengeTTL string
+
+	PasswordResetTTL string
+	Notifier         string
+	NotifierFilePath string
+
+	UserDeleteGracePeriod string
+	UserPurgeInterval     string
+
+	SessionCleanupInterval string
 }
 
 var Env *env
@@ -30,8 +62,16 @@ func LoadEnv() error {
 	}
 
 	jwtSecret := os.Getenv("JWT_SECRET_KEY")
-	if jwtSecret == "" {
-		return errors.New("JWT_SECRET_KEY is not set")
+	jwtSigningKeyPath := os.Getenv("JWT_SIGNING_KEY_PATH")
+	if jwtSecret == "" && jwtSigningKeyPath == "" {
+		return errors.New("JWT_SECRET_KEY or JWT_SIGNING_KEY_PATH must be set")
+	}
+
+	var jwtPublicKeyPaths []string
+	for _, path := range strings.Split(os.Getenv("JWT_PUBLIC_KEY_PATHS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			jwtPublicKeyPaths = append(jwtPublicKeyPaths, path)
+		}
 	}
 
 	port := getEnv("GRPC_PORT", "50051")
@@ -39,12 +79,82 @@ func LoadEnv() error {
 	accessTokenTTL := getEnv("ACCESS_TOKEN_TTL", "15m")
 	refreshTokenTTL := getEnv("REFRESH_TOKEN_TTL", "24h")
 
+	loginFreeAttempts := getEnv("LOGIN_FREE_ATTEMPTS", "3")
+	loginLockoutAttempts := getEnv("LOGIN_LOCKOUT_ATTEMPTS", "10")
+	loginLockoutDuration := getEnv("LOGIN_LOCKOUT_DURATION", "15m")
+
+	passwordMinLength := getEnv("PASSWORD_MIN_LENGTH", "8")
+	passwordMinClasses := getEnv("PASSWORD_MIN_CLASSES", "3")
+	passwordForbidUsername := getEnv("PASSWORD_FORBID_USERNAME", "true")
+
+	passwordHashAlgorithm := getEnv("PASSWORD_HASH_ALGORITHM", "argon2id")
+	bcryptCost := getEnv("BCRYPT_COST", "10")
+	argon2Time := getEnv("ARGON2_TIME", "3")
+	argon2Memory := getEnv("ARGON2_MEMORY_KIB", "65536")
+	argon2Threads := getEnv("ARGON2_THREADS", "2")
+
+	totpIssuer := getEnv("TOTP_ISSUER", "chatler")
+	mfaChallengeTTL := getEnv("MFA_CHALLENGE_TTL", "5m")
+
+	passwordResetTTL := getEnv("PASSWORD_RESET_TTL", "15m")
+	notifier := getEnv("NOTIFIER", "log")
+	notifierFilePath := getEnv("NOTIFIER_FILE_PATH", "./notifications.log")
+
+	userDeleteGracePeriod := getEnv("USER_DELETE_GRACE_PERIOD", "720h")
+	userPurgeInterval := getEnv("USER_PURGE_INTERVAL", "1h")
+
+	sessionCleanupInterval := getEnv("SESSION_CLEANUP_INTERVAL", "1h")
+
+	durations := []struct{ key, value string }{
+		{"ACCESS_TOKEN_TTL", accessTokenTTL},
+		{"REFRESH_TOKEN_TTL", refreshTokenTTL},
+		{"LOGIN_LOCKOUT_DURATION", loginLockoutDuration},
+		{"MFA_CHALLENGE_TTL", mfaChallengeTTL},
+		{"PASSWORD_RESET_TTL", passwordResetTTL},
+		{"USER_DELETE_GRACE_PERIOD", userDeleteGracePeriod},
+		{"USER_PURGE_INTERVAL", userPurgeInterval},
+		{"SESSION_CLEANUP_INTERVAL", sessionCleanupInterval},
+	}
+	for _, d := range durations {
+		if _, err := ParseDuration(d.value); err != nil {
+			return fmt.Errorf("%s: %w", d.key, err)
+		}
+	}
+
 	env := &env{
-		SqlitePath:      sqdsn,
-		GRPCPort:        port,
-		JWTSecret:       jwtSecret,
-		AccessTokenTTL:  accessTokenTTL,
-		RefreshTokenTTL: refreshTokenTTL,
+		SqlitePath:        sqdsn,
+		GRPCPort:          port,
+		JWTSecret:         jwtSecret,
+		JWTSigningKeyPath: jwtSigningKeyPath,
+		JWTPublicKeyPaths: jwtPublicKeyPaths,
+		AccessTokenTTL:    accessTokenTTL,
+		RefreshTokenTTL:   refreshTokenTTL,
+
+		LoginFreeAttempts:    loginFreeAttempts,
+		LoginLockoutAttempts: loginLockoutAttempts,
+		LoginLockoutDuration: loginLockoutDuration,
+
+		PasswordMinLength:      passwordMinLength,
+		PasswordMinClasses:     passwordMinClasses,
+		PasswordForbidUsername: passwordForbidUsername,
+
+		PasswordHashAlgorithm: passwordHashAlgorithm,
+		BcryptCost:            bcryptCost,
+		Argon2Time:            argon2Time,
+		Argon2Memory:          argon2Memory,
+		Argon2Threads:         argon2Threads,
+
+		TOTPIssuer:      totpIssuer,
+		MFAChallengeTTL: mfaChallengeTTL,
+
+		PasswordResetTTL: passwordResetTTL,
+		Notifier:         notifier,
+		NotifierFilePath: notifierFilePath,
+
+		UserDeleteGracePeriod: userDeleteGracePeriod,
+		UserPurgeInterval:     userPurgeInterval,
+
+		SessionCleanupInterval: sessionCleanupInterval,
 	}
 
 	Env = env
@@ -59,3 +169,22 @@ func getEnv(key, defaultValue string) string {
 	log.Printf("Using default %s: %s\n", key, defaultValue)
 	return defaultValue
 }
+
+// ParseDuration parses a duration setting, either a Go duration such as
+// "90m" or a whole number of hours. Durations must be positive.
+func ParseDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		hours, atoiErr := strconv.Atoi(value)
+		if atoiErr != nil {
+			return 0, err
+		}
+		duration = time.Duration(hours) * time.Hour
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", value)
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadEnv runs LoadEnv in a temporary directory with the minimum
+// required settings and the given variables.
+func loadEnv(t *testing.T, vars map[string]string) error {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	t.Setenv("SQLITE_PATH", filepath.Join(dir, "auth.db"))
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	for key, value := range vars {
+		t.Setenv(key, value)
+	}
+
+	return LoadEnv()
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"15m", 15 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"720", 720 * time.Hour},
+		{"1", time.Hour},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "0", "0s", "-1h", "-5", "1.5"} {
+		if got, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) = %v, want an error", value, got)
+		}
+	}
+}
+
+func TestLoadEnvRejectsInvalidDurations(t *testing.T) {
+	for _, key := range []string{
+		"MFA_CHALLENGE_TTL",
+		"USER_DELETE_GRACE_PERIOD",
+		"USER_PURGE_INTERVAL",
+		"SESSION_CLEANUP_INTERVAL",
+	} {
+		for _, value := range []string{"abc", "0", "-1h"} {
+			t.Run(key+"="+value, func(t *testing.T) {
+				err := loadEnv(t, map[string]string{key: value})
+				if err == nil || !strings.Contains(err.Error(), key) {
+					t.Fatalf("LoadEnv = %v, want an error naming %s", err, key)
+				}
+			})
+		}
+	}
+
+	if err := loadEnv(t, map[string]string{"SESSION_CLEANUP_INTERVAL": "2"}); err != nil {
+		t.Fatalf("LoadEnv with an interval in hours: %v", err)
+	}
+	if err := loadEnv(t, map[string]string{"USER_DELETE_GRACE_PERIOD": "720"}); err != nil {
+		t.Fatalf("LoadEnv with a grace period in hours: %v", err)
+	}
+}
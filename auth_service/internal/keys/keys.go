@@ -0,0 +1,238 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrUnknownKeyID   = errors.New("unknown key id")
+)
+
+// JWK is the public part of a verification key in RFC 7517 form.
+type JWK struct {
+	KeyID     string
+	KeyType   string
+	Algorithm string
+	Use       string
+	Curve     string
+	X         string
+	N         string
+	E         string
+}
+
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Key    crypto.Signer
+}
+
+// KeySet holds the key used to sign new access tokens and every public
+// key that tokens may still be verified with. Keeping the previous
+// public keys around after switching the signing key lets tokens issued
+// before the rotation live out their TTL.
+type KeySet struct {
+	signing      *SigningKey
+	verification map[string]crypto.PublicKey
+}
+
+// Load reads a PEM private signing key and any number of PEM public
+// keys. The signing key's public half is always a verification key.
+func Load(signingKeyPath string, publicKeyPaths []string) (*KeySet, error) {
+	op := "keys.Load"
+
+	set := &KeySet{
+		verification: make(map[string]crypto.PublicKey),
+	}
+
+	if signingKeyPath != "" {
+		signer, err := readPrivateKey(signingKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		method, err := signingMethod(signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, signingKeyPath, err)
+		}
+
+		kid, err := KeyID(signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		set.signing = &SigningKey{ID: kid, Method: method, Key: signer}
+		set.verification[kid] = signer.Public()
+	}
+
+	for _, path := range publicKeyPaths {
+		key, err := readPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := signingMethod(key); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, path, err)
+		}
+
+		kid, err := KeyID(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		set.verification[kid] = key
+	}
+
+	return set, nil
+}
+
+// Signing returns the active signing key, or nil when tokens are still
+// signed with the shared HS256 secret.
+func (s *KeySet) Signing() *SigningKey {
+	return s.signing
+}
+
+func (s *KeySet) VerificationKey(kid string) (crypto.PublicKey, error) {
+	key, ok := s.verification[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	return key, nil
+}
+
+func (s *KeySet) PublicKeys() []JWK {
+	jwks := make([]JWK, 0, len(s.verification))
+	for kid, key := range s.verification {
+		jwk, err := ToJWK(kid, key)
+		if err != nil {
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+
+	sort.Slice(jwks, func(i, j int) bool {
+		return jwks[i].KeyID < jwks[j].KeyID
+	})
+
+	return jwks
+}
+
+// KeyID derives a stable kid from the key's DER encoding, so replicas
+// loading the same files agree on it without extra configuration.
+func KeyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+func ToJWK(kid string, key crypto.PublicKey) (JWK, error) {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return JWK{
+			KeyID:     kid,
+			KeyType:   "OKP",
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Use:       "sig",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	case *rsa.PublicKey:
+		return JWK{
+			KeyID:     kid,
+			KeyType:   "RSA",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Use:       "sig",
+			N:         base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	default:
+		return JWK{}, ErrUnsupportedKey
+	}
+}
+
+func signingMethod(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, ErrUnsupportedKey)
+	}
+
+	return signer, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	return block, nil
+}
@@ -0,0 +1,248 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func writePrivateKey(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writePEM(t, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writePEM(t, "PUBLIC KEY", der)
+}
+
+func TestLoadWithoutKeys(t *testing.T) {
+	set, err := Load("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if set.Signing() != nil {
+		t.Fatal("Signing() is not nil without a signing key")
+	}
+	if len(set.PublicKeys()) != 0 {
+		t.Fatal("PublicKeys() is not empty without keys")
+	}
+}
+
+func TestLoadEd25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := Load(writePrivateKey(t, private), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signing := set.Signing()
+	if signing.Method != jwt.SigningMethodEdDSA {
+		t.Fatalf("Method = %v, want EdDSA", signing.Method.Alg())
+	}
+
+	kid, err := KeyID(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signing.ID != kid {
+		t.Fatalf("ID = %q, want %q", signing.ID, kid)
+	}
+
+	jwks := set.PublicKeys()
+	if len(jwks) != 1 {
+		t.Fatalf("got %d public keys, want 1", len(jwks))
+	}
+	jwk := jwks[0]
+	if jwk.KeyID != kid || jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" ||
+		jwk.Algorithm != "EdDSA" || jwk.Use != "sig" {
+		t.Fatalf("unexpected JWK %+v", jwk)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !public.Equal(ed25519.PublicKey(x)) {
+		t.Fatal("JWK x does not encode the public key")
+	}
+}
+
+func TestLoadRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PKCS #1 keys are accepted as well as PKCS #8.
+	path := writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private))
+	set, err := Load(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if set.Signing().Method != jwt.SigningMethodRS256 {
+		t.Fatalf("Method = %v, want RS256", set.Signing().Method.Alg())
+	}
+
+	jwk := set.PublicKeys()[0]
+	if jwk.KeyType != "RSA" || jwk.Algorithm != "RS256" {
+		t.Fatalf("unexpected JWK %+v", jwk)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+	if !private.PublicKey.Equal(decoded) {
+		t.Fatal("JWK n and e do not encode the public key")
+	}
+}
+
+func TestLoadKeepsPreviousPublicKeys(t *testing.T) {
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPath := writePEM(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+
+	set, err := Load(
+		writePrivateKey(t, current),
+		[]string{writePublicKey(t, previous), rsaPath},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := set.PublicKeys()
+	if len(jwks) != 3 {
+		t.Fatalf("got %d public keys, want 3", len(jwks))
+	}
+	for i := 1; i < len(jwks); i++ {
+		if jwks[i-1].KeyID >= jwks[i].KeyID {
+			t.Fatalf("public keys are not sorted by kid: %q, %q", jwks[i-1].KeyID, jwks[i].KeyID)
+		}
+	}
+
+	previousID, err := KeyID(previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := set.VerificationKey(previousID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !previous.Equal(key) {
+		t.Fatal("VerificationKey returned another key")
+	}
+
+	if _, err := set.VerificationKey("unknown"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("VerificationKey(unknown) = %v, want ErrUnknownKeyID", err)
+	}
+}
+
+func TestLoadRejectsUnsupportedKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(writePrivateKey(t, ecKey), nil); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("Load(ECDSA signing key) = %v, want ErrUnsupportedKey", err)
+	}
+	if _, err := Load("", []string{writePublicKey(t, &ecKey.PublicKey)}); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("Load(ECDSA public key) = %v, want ErrUnsupportedKey", err)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path, nil); err == nil {
+		t.Fatal("Load accepted a file without PEM data")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.pem"), nil); err == nil {
+		t.Fatal("Load accepted a missing file")
+	}
+}
+
+func TestKeyIDIsStable(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := KeyID(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := KeyID(ed25519.PublicKey(append([]byte(nil), public...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first != second {
+		t.Fatalf("KeyID differs for the same key: %q, %q", first, second)
+	}
+}
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN family_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN rotated_at TIMESTAMP;
+UPDATE sessions SET family_id = id WHERE family_id = '';
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions (family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sessions_family_id;
+ALTER TABLE sessions DROP COLUMN rotated_at;
+ALTER TABLE sessions DROP COLUMN family_id;
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"auth.service/pkg"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upHashRefreshTokens, downHashRefreshTokens)
+}
+
+func upHashRefreshTokens(ctx context.Context, tx *sql.Tx) error {
+	op := "migrations.upHashRefreshTokens"
+
+	statements := []string{
+		`DROP INDEX IF EXISTS idx_sessions_refresh_token`,
+		`ALTER TABLE sessions RENAME COLUMN refresh_token TO refresh_token_hash`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_refresh_token_hash ON sessions (refresh_token_hash)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	err := backfillColumn(
+		ctx,
+		tx,
+		"sessions",
+		"id",
+		"refresh_token_hash",
+		"refresh_token_hash",
+		pkg.HashToken,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// downHashRefreshTokens cannot recover the plaintext tokens, so every
+// session is dropped and users have to log in again.
+func downHashRefreshTokens(ctx context.Context, tx *sql.Tx) error {
+	op := "migrations.downHashRefreshTokens"
+
+	statements := []string{
+		`DELETE FROM sessions`,
+		`DROP INDEX IF EXISTS idx_sessions_refresh_token_hash`,
+		`ALTER TABLE sessions RENAME COLUMN refresh_token_hash TO refresh_token`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_refresh_token ON sessions (refresh_token)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"auth.service/pkg"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upNormalizedUsernames, downNormalizedUsernames)
+}
+
+// upNormalizedUsernames fills normalized_username for existing users.
+// If two users only differ by case or Unicode form the migration fails
+// and names them, since picking which account keeps the name is not
+// something a migration should decide.
+func upNormalizedUsernames(ctx context.Context, tx *sql.Tx) error {
+	op := "migrations.upNormalizedUsernames"
+
+	_, err := tx.ExecContext(
+		ctx,
+		`ALTER TABLE users ADD COLUMN normalized_username TEXT NOT NULL DEFAULT ''`,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = backfillColumn(
+		ctx,
+		tx,
+		"users",
+		"id",
+		"username",
+		"normalized_username",
+		pkg.NormalizeUsername,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var conflicts string
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(GROUP_CONCAT(usernames, ', '), '')
+		FROM (
+			SELECT GROUP_CONCAT(username, ' = ') AS usernames
+			FROM users
+			GROUP BY normalized_username
+			HAVING COUNT(*) > 1
+		)
+	`).Scan(&conflicts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if conflicts != "" {
+		return fmt.Errorf(
+			"%s: usernames collide after normalization: %s",
+			op,
+			conflicts,
+		)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_normalized_username ON users (normalized_username)`,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func downNormalizedUsernames(ctx context.Context, tx *sql.Tx) error {
+	op := "migrations.downNormalizedUsernames"
+
+	statements := []string{
+		`DROP INDEX IF EXISTS idx_users_normalized_username`,
+		`ALTER TABLE users DROP COLUMN normalized_username`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- There is no RPC to grant roles; promote admins by hand with
+-- UPDATE users SET role = 'admin' WHERE normalized_username = '...';
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"auth.service/pkg"
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upProfileSearch, downProfileSearch)
+}
+
+// upProfileSearch adds the case-folded display name user search matches
+// against. Folding needs Go's Unicode tables, so existing rows are
+// filled here rather than in SQL.
+func upProfileSearch(ctx context.Context, tx *sql.Tx) error {
+	op := "migrations.upProfileSearch"
+
+	_, err := tx.ExecContext(
+		ctx,
+		`ALTER TABLE profiles ADD COLUMN normalized_display_name TEXT NOT NULL DEFAULT ''`,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = backfillColumn(
+		ctx,
+		tx,
+		"profiles",
+		"user_id",
+		"display_name",
+		"normalized_display_name",
+		pkg.FoldText,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func downProfileSearch(ctx context.Context, tx *sql.Tx) error {
+	op := "migrations.downProfileSearch"
+
+	_, err := tx.ExecContext(
+		ctx,
+		`ALTER TABLE profiles DROP COLUMN normalized_display_name`,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_token_revocations ADD COLUMN kept_jti TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_token_revocations DROP COLUMN kept_jti;
+-- +goose StatementEnd
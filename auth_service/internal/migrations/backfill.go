@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// backfillColumn sets column to fill(source) on every row of table,
+// where rows are identified by key. Values that need Go code, such as
+// hashes or Unicode folding, cannot be computed in SQL, so the rows are
+// read in full first and then updated one by one.
+func backfillColumn(
+	ctx context.Context,
+	tx *sql.Tx,
+	table, key, source, column string,
+	fill func(string) string,
+) error {
+	rows, err := tx.QueryContext(
+		ctx,
+		fmt.Sprintf(`SELECT %s, %s FROM %s`, key, source, table),
+	)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var id, value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		values[id] = fill(value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s = ?`, table, column, key)
+	for id, value := range values {
+		if _, err := tx.ExecContext(ctx, update, value, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+// Package migrations holds the goose migrations of the auth database.
+// SQL migrations are embedded; Go migrations register themselves in
+// init, so they only run through cmd/migrate, not the stock goose CLI.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,165 @@
+package migrations_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"auth.service/internal/migrations"
+	"auth.service/pkg"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+)
+
+// openDB returns a new database migrated up to version.
+func openDB(t *testing.T, version int64) *sqlx.DB {
+	t.Helper()
+
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "auth.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	goose.SetBaseFS(migrations.FS)
+	goose.SetLogger(goose.NopLogger())
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatal(err)
+	}
+	migrateTo(t, db, version)
+
+	return db
+}
+
+func migrateTo(t *testing.T, db *sqlx.DB, version int64) {
+	t.Helper()
+
+	if err := goose.UpToContext(context.Background(), db.DB, ".", version); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func insertUser(t *testing.T, db *sqlx.DB, id, username string) {
+	t.Helper()
+
+	now := time.Now()
+	_, err := db.Exec(
+		`INSERT INTO users (id, username, password_hash, created_at, updated_at)
+		VALUES (?, ?, '', ?, ?)`,
+		id, username, now, now,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHashRefreshTokens(t *testing.T) {
+	db := openDB(t, 20250424100000)
+
+	insertUser(t, db, "u1", "alice")
+	for id, token := range map[string]string{"s1": "token-1", "s2": "token-2"} {
+		_, err := db.Exec(
+			`INSERT INTO sessions (id, user_id, refresh_token, family_id, created_at, expires_at)
+			VALUES (?, 'u1', ?, ?, ?, ?)`,
+			id, token, id, time.Now(), time.Now().Add(time.Hour),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrateTo(t, db, 20250425100000)
+
+	for id, token := range map[string]string{"s1": "token-1", "s2": "token-2"} {
+		var hash string
+		if err := db.Get(&hash, `SELECT refresh_token_hash FROM sessions WHERE id = ?`, id); err != nil {
+			t.Fatal(err)
+		}
+		if hash != pkg.HashToken(token) {
+			t.Fatalf("session %s: refresh_token_hash = %q, want hash of %q", id, hash, token)
+		}
+	}
+}
+
+func TestNormalizedUsernames(t *testing.T) {
+	db := openDB(t, 20250426090000)
+
+	insertUser(t, db, "u1", "Alice")
+	insertUser(t, db, "u2", "ｂｏｂ")
+
+	migrateTo(t, db, 20250427090000)
+
+	for id, want := range map[string]string{"u1": "alice", "u2": "bob"} {
+		var normalized string
+		if err := db.Get(&normalized, `SELECT normalized_username FROM users WHERE id = ?`, id); err != nil {
+			t.Fatal(err)
+		}
+		if normalized != want {
+			t.Fatalf("user %s: normalized_username = %q, want %q", id, normalized, want)
+		}
+	}
+}
+
+func TestNormalizedUsernamesRejectsCollisions(t *testing.T) {
+	db := openDB(t, 20250426090000)
+
+	insertUser(t, db, "u1", "Alice")
+	insertUser(t, db, "u2", "ALICE")
+	insertUser(t, db, "u3", "bob")
+
+	err := goose.UpToContext(context.Background(), db.DB, ".", 20250427090000)
+	if err == nil {
+		t.Fatal("migration succeeded despite colliding usernames")
+	}
+	if !strings.Contains(err.Error(), "Alice") || !strings.Contains(err.Error(), "ALICE") {
+		t.Fatalf("error %q does not name the colliding usernames", err)
+	}
+	if strings.Contains(err.Error(), "bob") {
+		t.Fatalf("error %q names a username without collision", err)
+	}
+
+	// The failed migration is rolled back.
+	version, err := goose.GetDBVersion(db.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 20250426090000 {
+		t.Fatalf("version = %d, want 20250426090000", version)
+	}
+}
+
+func TestProfileSearchFoldsDisplayNames(t *testing.T) {
+	db := openDB(t, 20250503090000)
+
+	insertUser(t, db, "u1", "alice")
+	_, err := db.Exec(
+		`INSERT INTO profiles (user_id, display_name, updated_at) VALUES ('u1', ?, ?)`,
+		"Ｊösé ÁLVAREZ",
+		time.Now(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrateTo(t, db, 20250504090000)
+
+	var normalized string
+	if err := db.Get(&normalized, `SELECT normalized_display_name FROM profiles WHERE user_id = 'u1'`); err != nil {
+		t.Fatal(err)
+	}
+	if want := pkg.FoldText("Ｊösé ÁLVAREZ"); normalized != want {
+		t.Fatalf("normalized_display_name = %q, want %q", normalized, want)
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openDB(t, 20250504090000)
+
+	migrateTo(t, db, goose.MaxVersion)
+	if err := goose.DownToContext(context.Background(), db.DB, ".", 0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -11,9 +11,12 @@ type User struct {
 }
 
 type Session struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"user_id"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID               string     `json:"id"`
+	UserID           string     `json:"user_id"`
+	RefreshTokenHash string     `json:"refresh_token_hash"`
+	Device           string     `json:"device"`
+	FamilyID         string     `json:"family_id"`
+	RotatedAt        *time.Time `json:"rotated_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at"`
 }
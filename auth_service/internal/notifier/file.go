@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileNotifier appends one JSON object per message to a file, which a
+// local mail catcher or a test can tail.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+type fileMessage struct {
+	Type      string    `json:"type"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+func (n *FileNotifier) SendPasswordReset(
+	ctx context.Context,
+	reset *PasswordReset,
+) error {
+	op := "notifier.FileNotifier.SendPasswordReset"
+
+	line, err := json.Marshal(fileMessage{
+		Type:      "password_reset",
+		UserID:    reset.UserID,
+		Username:  reset.Username,
+		Token:     reset.Token,
+		ExpiresAt: reset.ExpiresAt,
+		SentAt:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
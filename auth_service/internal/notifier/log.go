@@ -0,0 +1,29 @@
+package notifier
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// LogNotifier writes messages to the service log. It is meant for local
+// development only: reset tokens end up in plain text in the logs.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) SendPasswordReset(
+	ctx context.Context,
+	reset *PasswordReset,
+) error {
+	log.Printf(
+		"password reset for %s: token %s, expires at %s",
+		reset.Username,
+		reset.Token,
+		reset.ExpiresAt.Format(time.RFC3339),
+	)
+
+	return nil
+}
@@ -0,0 +1,35 @@
+// Package notifier delivers messages to users outside of the API, such
+// as password reset tokens. Users have no contact details yet, so
+// messages are addressed by username and it is up to the
+// implementation to route them.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type PasswordReset struct {
+	UserID    string
+	Username  string
+	Token     string
+	ExpiresAt time.Time
+}
+
+type Notifier interface {
+	SendPasswordReset(ctx context.Context, reset *PasswordReset) error
+}
+
+// New returns the notifier for kind, "log" or "file". The file notifier
+// appends to path.
+func New(kind, path string) (Notifier, error) {
+	switch kind {
+	case "log":
+		return NewLogNotifier(), nil
+	case "file":
+		return NewFileNotifier(path), nil
+	default:
+		return nil, fmt.Errorf("notifier.New: unknown notifier %q", kind)
+	}
+}
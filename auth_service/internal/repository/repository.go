@@ -6,38 +6,287 @@ import (
 	"time"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+
+	StatusActive   = "active"
+	StatusDisabled = "disabled"
+	StatusBanned   = "banned"
+)
+
 var (
-	ErrUserNotFound      = errors.New("user not found")
-	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrUserAlreadyExists    = errors.New("user already exists")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrAttemptNotFound      = errors.New("login attempt not found")
+	ErrTOTPNotFound         = errors.New("totp secret not found")
+	ErrTOTPStepUsed         = errors.New("totp code already used")
+	ErrRecoveryCodeNotFound = errors.New("recovery code not found")
+	ErrChallengeNotFound    = errors.New("mfa challenge not found")
+	ErrResetTokenNotFound   = errors.New("password reset token not found")
 )
 
 type User struct {
-	ID           string    `db:"id"`
-	Username     string    `db:"username"`
-	PasswordHash string    `db:"password_hash"`
-	CreatedAt    time.Time `db:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at"`
+	ID                 string     `db:"id"`
+	Username           string     `db:"username"`
+	NormalizedUsername string     `db:"normalized_username"`
+	PasswordHash       string     `db:"password_hash"`
+	Role               string     `db:"role"`
+	Status             string     `db:"status"`
+	StatusReason       string     `db:"status_reason"`
+	StatusUntil        *time.Time `db:"status_until"`
+	CreatedAt          time.Time  `db:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+	DeletedAt          *time.Time `db:"deleted_at"`
 }
 
 type Session struct {
-	ID           string    `db:"id"`
-	UserID       string    `db:"user_id"`
-	RefreshToken string    `db:"refresh_token"`
-	ExpiresAt    time.Time `db:"expires_at"`
-	CreatedAt    time.Time `db:"created_at"`
+	ID               string     `db:"id"`
+	UserID           string     `db:"user_id"`
+	RefreshToken     string     `db:"-"` // plaintext, only set on create
+	RefreshTokenHash string     `db:"refresh_token_hash"`
+	Device           string     `db:"device"`
+	FamilyID         string     `db:"family_id"`
+	RotatedAt        *time.Time `db:"rotated_at"`
+	AccessTokenJTI   string     `db:"access_token_jti"` // issued with the refresh token
+	ExpiresAt        time.Time  `db:"expires_at"`
+	CreatedAt        time.Time  `db:"created_at"`
+}
+
+type RevokedToken struct {
+	JTI       string    `db:"jti"`
+	UserID    string    `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+	RevokedAt time.Time `db:"revoked_at"`
+}
+
+// UserTokenRevocation revokes the user's access tokens issued at or
+// before RevokedBefore, except the one with ID KeptJTI.
+type UserTokenRevocation struct {
+	UserID        string    `db:"user_id"`
+	RevokedBefore time.Time `db:"revoked_before"`
+	KeptJTI       string    `db:"kept_jti"`
+}
+
+type LoginAttempt struct {
+	Key           string    `db:"key"`
+	Failures      int       `db:"failures"`
+	LastFailureAt time.Time `db:"last_failure_at"`
+	BlockedUntil  time.Time `db:"blocked_until"`
+}
+
+type TOTPSecret struct {
+	UserID       string     `db:"user_id"`
+	Secret       string     `db:"secret"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+type MFAChallenge struct {
+	ID        string    `db:"id"`
+	Token     string    `db:"-"` // plaintext, only set on create
+	TokenHash string    `db:"token_hash"`
+	UserID    string    `db:"user_id"`
+	Device    string    `db:"device"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        string    `db:"id"`
+	Token     string    `db:"-"` // plaintext, only set on create
+	TokenHash string    `db:"token_hash"`
+	UserID    string    `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Profile holds the public profile of a user. Users who never set one
+// have a Profile with empty fields and a nil UpdatedAt.
+type Profile struct {
+	UserID             string     `db:"user_id"`
+	Username           string     `db:"username"`
+	DisplayName        string     `db:"display_name"`
+	Bio                string     `db:"bio"`
+	AvatarAttachmentID string     `db:"avatar_attachment_id"`
+	StatusText         string     `db:"status_text"`
+	UpdatedAt          *time.Time `db:"updated_at"`
+}
+
+// ProfileMatch is a user search result. Rank is 0 for an exact username
+// match, 1 for a username prefix, 2 for a display name or display name
+// word prefix, 3 for a substring and 4 for a fuzzy match.
+type ProfileMatch struct {
+	Profile
+	NormalizedUsername string `db:"normalized_username"`
+	NameLength         int    `db:"name_length"`
+	Rank               int    `db:"rank"`
+}
+
+// ProfileSearchCursor is the last match of the previous page.
+type ProfileSearchCursor struct {
+	Rank               int
+	NameLength         int
+	NormalizedUsername string
+	UserID             string
 }
 
+type ProfileSearchQuery struct {
+	// Query must already be folded with pkg.FoldText.
+	Query string
+	After *ProfileSearchCursor
+	Limit int
+}
+
+const (
+	UserOrderUsername  = "username"
+	UserOrderCreatedAt = "created_at"
+)
+
+// UserCursor is the last user of the previous page. Only the field of
+// the requested order is used, with ID breaking ties.
+type UserCursor struct {
+	NormalizedUsername string
+	CreatedAt          time.Time
+	ID                 string
+}
+
+type UserListQuery struct {
+	// UsernamePrefix is matched against normalized usernames.
+	UsernamePrefix string
+	OrderBy        string
+	Desc           bool
+	After          *UserCursor
+	Limit          int
+}
+
+// UserRepository only returns users that are not soft-deleted, except
+// for DeletedUserByUsername. A deleted user keeps its username until it
+// is purged.
 type UserRepository interface {
+	// CreateUser returns ErrUserAlreadyExists if the username is taken,
+	// including by a deleted user that has not been purged yet.
 	CreateUser(ctx context.Context, user *User) error
 	UserByID(ctx context.Context, id string) (*User, error)
+	// UserByUsername matches usernames by their normalized form, so the
+	// lookup ignores case and Unicode width.
 	UserByUsername(ctx context.Context, username string) (*User, error)
+	DeletedUserByUsername(ctx context.Context, username string) (*User, error)
+	// UsersByIDs and UsersByUsernames fetch many users with one query and
+	// skip the ones that do not exist.
+	UsersByIDs(ctx context.Context, ids []string) ([]*User, error)
+	UsersByUsernames(ctx context.Context, usernames []string) ([]*User, error)
 	UpdateUser(ctx context.Context, user *User) error
+	// DeleteUser soft-deletes the user by setting deleted_at.
 	DeleteUser(ctx context.Context, id string) error
+	// RestoreUser undoes DeleteUser if the user was deleted after
+	// deletedAfter, and returns ErrUserNotFound otherwise.
+	RestoreUser(ctx context.Context, id string, deletedAfter time.Time) error
+	// PurgeDeletedUsers hard-deletes users deleted at or before
+	// deletedBefore together with their data, and returns how many
+	// users were removed.
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ListUsers(ctx context.Context, query UserListQuery) ([]*User, error)
+	SetUserStatus(ctx context.Context, id, status, reason string, until *time.Time) error
 }
 
 type SessionRepository interface {
 	CreateSession(ctx context.Context, session *Session) error
 	GetByRefreshToken(ctx context.Context, refreshToken string) (*Session, error)
+	SessionByID(ctx context.Context, id string) (*Session, error)
+	SessionsByUserID(ctx context.Context, userID string) ([]*Session, error)
+	MarkRotated(ctx context.Context, id string) error
 	DeleteSession(ctx context.Context, id string) error
+	// DeleteByFamilyID deletes a token family and returns its sessions,
+	// so that the access tokens issued with them can be revoked.
+	DeleteByFamilyID(ctx context.Context, familyID string) ([]*Session, error)
 	DeleteByUserID(ctx context.Context, userID string) error
+	// DeleteExpiredFamilies deletes every token family whose newest
+	// session expired at or before now, and returns how many sessions
+	// were removed. Rotated sessions stay until then, so reuse of their
+	// refresh tokens is detected for as long as the family is alive.
+	DeleteExpiredFamilies(ctx context.Context, now time.Time) (int64, error)
+}
+
+type RevocationRepository interface {
+	RevokeToken(ctx context.Context, token *RevokedToken) error
+	RevokedTokens(ctx context.Context, now time.Time) ([]*RevokedToken, error)
+	DeleteExpiredTokens(ctx context.Context, now time.Time) error
+	RevokeUserTokens(ctx context.Context, revocation *UserTokenRevocation) error
+	// KeepUserToken sets the KeptJTI of the user's revocation.
+	KeepUserToken(ctx context.Context, userID, jti string) error
+	UserTokenRevocations(ctx context.Context, since time.Time) ([]*UserTokenRevocation, error)
+}
+
+type LoginAttemptRepository interface {
+	AttemptByKey(ctx context.Context, key string) (*LoginAttempt, error)
+	// ReserveAttempts counts a failure for every key in one transaction,
+	// so concurrent attempts cannot slip past a block: for each key it
+	// forgets failures last seen before resetBefore, adds one and blocks
+	// the key for delay(failures) from now. If any key is still blocked
+	// at now, nothing is counted, reserved is false and blockedUntil is
+	// the latest block among the keys.
+	ReserveAttempts(
+		ctx context.Context,
+		keys []string,
+		now, resetBefore time.Time,
+		delay func(failures int) time.Duration,
+	) (blockedUntil time.Time, reserved bool, err error)
+	// ReleaseAttempt takes back one failure counted by ReserveAttempts and
+	// blocks the key for delay(failures) of the remaining ones from now.
+	ReleaseAttempt(
+		ctx context.Context,
+		key string,
+		now time.Time,
+		delay func(failures int) time.Duration,
+	) error
+	DeleteAttempt(ctx context.Context, key string) error
+	// DeleteStaleAttempts removes keys whose last failure was before
+	// lastFailureBefore and that are no longer blocked at now.
+	DeleteStaleAttempts(ctx context.Context, lastFailureBefore, now time.Time) (int64, error)
+}
+
+type MFARepository interface {
+	TOTPByUserID(ctx context.Context, userID string) (*TOTPSecret, error)
+	// SaveTOTP replaces the user's secret, e.g. when re-enrolling before
+	// the previous enrollment was confirmed.
+	SaveTOTP(ctx context.Context, secret *TOTPSecret) error
+	ConfirmTOTP(ctx context.Context, userID string, step int64) error
+	// UseTOTPStep records step as the last accepted one and returns
+	// ErrTOTPStepUsed if it is not newer than the previous one.
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
+	// DeleteTOTP removes the secret together with the recovery codes.
+	DeleteTOTP(ctx context.Context, userID string) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codes []string) error
+	// UseRecoveryCode consumes a recovery code, returning
+	// ErrRecoveryCodeNotFound if it does not exist.
+	UseRecoveryCode(ctx context.Context, userID, code string) error
+
+	CreateChallenge(ctx context.Context, challenge *MFAChallenge) error
+	ChallengeByToken(ctx context.Context, token string) (*MFAChallenge, error)
+	DeleteChallenge(ctx context.Context, id string) error
+}
+
+type PasswordResetRepository interface {
+	// CreateResetToken stores a new token and drops the user's previous
+	// ones, so only the latest reset link works.
+	CreateResetToken(ctx context.Context, token *PasswordResetToken) error
+	ResetTokenByToken(ctx context.Context, token string) (*PasswordResetToken, error)
+	DeleteResetToken(ctx context.Context, id string) error
+}
+
+type ProfileRepository interface {
+	// ProfileByUserID returns ErrUserNotFound unless the user exists and
+	// is not deleted.
+	ProfileByUserID(ctx context.Context, userID string) (*Profile, error)
+	// ProfilesByUserIDs skips unknown and deleted users.
+	ProfilesByUserIDs(ctx context.Context, userIDs []string) ([]*Profile, error)
+	SaveProfile(ctx context.Context, profile *Profile) error
+	// SearchProfiles finds active users whose username or display name
+	// contains the query's characters in order, best matches first:
+	// by rank, then shorter usernames, then alphabetically.
+	SearchProfiles(ctx context.Context, query ProfileSearchQuery) ([]*ProfileMatch, error)
 }
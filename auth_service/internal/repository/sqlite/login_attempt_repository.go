@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth.service/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteLoginAttemptRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) *SqliteLoginAttemptRepository {
+	return &SqliteLoginAttemptRepository{db: db}
+}
+
+func (r *SqliteLoginAttemptRepository) AttemptByKey(
+	ctx context.Context,
+	key string,
+) (*repository.LoginAttempt, error) {
+	op := "repository.LoginAttemptRepository.AttemptByKey"
+	attempt := new(repository.LoginAttempt)
+
+	query := `
+		SELECT key, failures, last_failure_at, blocked_until
+		FROM login_attempts
+		WHERE key = ?
+	`
+
+	err := r.db.GetContext(ctx, attempt, query, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrAttemptNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attempt, nil
+}
+
+func (r *SqliteLoginAttemptRepository) ReserveAttempts(
+	ctx context.Context,
+	keys []string,
+	now, resetBefore time.Time,
+	delay func(failures int) time.Duration,
+) (time.Time, bool, error) {
+	op := "repository.LoginAttemptRepository.ReserveAttempts"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// The first upsert takes SQLite's write lock, which serializes
+	// concurrent reservations until this transaction ends. Blocked keys
+	// are left alone and return no row.
+	upsert := `
+		INSERT INTO login_attempts (key, failures, last_failure_at, blocked_until)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN last_failure_at < ? THEN 1
+				ELSE failures + 1
+			END,
+			last_failure_at = excluded.last_failure_at
+		WHERE blocked_until <= ?
+		RETURNING failures
+	`
+	block := `
+		UPDATE login_attempts
+		SET blocked_until = ?
+		WHERE key = ?
+	`
+	blockedUntilOf := `
+		SELECT blocked_until
+		FROM login_attempts
+		WHERE key = ?
+	`
+
+	var blockedUntil time.Time
+	for _, key := range keys {
+		var failures int
+		err := tx.GetContext(ctx, &failures, upsert, key, now, now, resetBefore, now)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return time.Time{}, false, fmt.Errorf("%s: %w", op, err)
+			}
+
+			var until time.Time
+			if err := tx.GetContext(ctx, &until, blockedUntilOf, key); err != nil {
+				return time.Time{}, false, fmt.Errorf("%s: %w", op, err)
+			}
+			if until.After(blockedUntil) {
+				blockedUntil = until
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, block, now.Add(delay(failures)), key); err != nil {
+			return time.Time{}, false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Rolling back keeps the other keys from being charged for an
+	// attempt that is not going to be made.
+	if !blockedUntil.IsZero() {
+		return blockedUntil, false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return time.Time{}, true, nil
+}
+
+func (r *SqliteLoginAttemptRepository) ReleaseAttempt(
+	ctx context.Context,
+	key string,
+	now time.Time,
+	delay func(failures int) time.Duration,
+) error {
+	op := "repository.LoginAttemptRepository.ReleaseAttempt"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE login_attempts
+		SET failures = MAX(failures - 1, 0)
+		WHERE key = ?
+		RETURNING failures
+	`
+
+	var failures int
+	if err := tx.GetContext(ctx, &failures, query, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `
+		UPDATE login_attempts
+		SET blocked_until = ?
+		WHERE key = ?
+	`
+	if _, err := tx.ExecContext(ctx, query, now.Add(delay(failures)), key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteLoginAttemptRepository) DeleteAttempt(
+	ctx context.Context,
+	key string,
+) error {
+	op := "repository.LoginAttemptRepository.DeleteAttempt"
+
+	query := `
+		DELETE FROM login_attempts
+		WHERE key = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteLoginAttemptRepository) DeleteStaleAttempts(
+	ctx context.Context,
+	lastFailureBefore, now time.Time,
+) (int64, error) {
+	op := "repository.LoginAttemptRepository.DeleteStaleAttempts"
+
+	query := `
+		DELETE FROM login_attempts
+		WHERE last_failure_at < ? AND blocked_until <= ?
+	`
+
+	res, err := r.db.ExecContext(ctx, query, lastFailureBefore, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
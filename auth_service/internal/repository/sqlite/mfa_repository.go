@@ -0,0 +1,363 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteMFARepository struct {
+	db *sqlx.DB
+}
+
+func NewMFARepository(db *sqlx.DB) *SqliteMFARepository {
+	return &SqliteMFARepository{db: db}
+}
+
+func (r *SqliteMFARepository) TOTPByUserID(
+	ctx context.Context,
+	userID string,
+) (*repository.TOTPSecret, error) {
+	op := "repository.MFARepository.TOTPByUserID"
+	secret := new(repository.TOTPSecret)
+
+	query := `
+		SELECT user_id, secret, confirmed_at, last_used_step, created_at
+		FROM totp_secrets
+		WHERE user_id = ?
+	`
+
+	err := r.db.GetContext(ctx, secret, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrTOTPNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, nil
+}
+
+func (r *SqliteMFARepository) SaveTOTP(
+	ctx context.Context,
+	secret *repository.TOTPSecret,
+) error {
+	op := "repository.MFARepository.SaveTOTP"
+
+	secret.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO totp_secrets (
+			user_id, secret, confirmed_at, last_used_step, created_at
+		)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = excluded.secret,
+			confirmed_at = excluded.confirmed_at,
+			last_used_step = excluded.last_used_step,
+			created_at = excluded.created_at
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		secret.UserID,
+		secret.Secret,
+		secret.ConfirmedAt,
+		secret.LastUsedStep,
+		secret.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteMFARepository) ConfirmTOTP(
+	ctx context.Context,
+	userID string,
+	step int64,
+) error {
+	op := "repository.MFARepository.ConfirmTOTP"
+
+	query := `
+		UPDATE totp_secrets
+		SET confirmed_at = ?, last_used_step = ?
+		WHERE user_id = ? AND confirmed_at IS NULL
+	`
+
+	res, err := r.db.ExecContext(ctx, query, time.Now(), step, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrTOTPNotFound)
+	}
+
+	return nil
+}
+
+func (r *SqliteMFARepository) UseTOTPStep(
+	ctx context.Context,
+	userID string,
+	step int64,
+) error {
+	op := "repository.MFARepository.UseTOTPStep"
+
+	query := `
+		UPDATE totp_secrets
+		SET last_used_step = ?
+		WHERE user_id = ? AND last_used_step < ?
+	`
+
+	res, err := r.db.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrTOTPStepUsed)
+	}
+
+	return nil
+}
+
+func (r *SqliteMFARepository) DeleteTOTP(
+	ctx context.Context,
+	userID string,
+) error {
+	op := "repository.MFARepository.DeleteTOTP"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM totp_secrets WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrTOTPNotFound)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM recovery_codes WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteMFARepository) ReplaceRecoveryCodes(
+	ctx context.Context,
+	userID string,
+	codes []string,
+) error {
+	op := "repository.MFARepository.ReplaceRecoveryCodes"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM recovery_codes WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	for _, code := range codes {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO recovery_codes (code_hash, user_id, created_at) VALUES (?, ?, ?)`,
+			pkg.HashToken(pkg.NormalizeRecoveryCode(code)),
+			userID,
+			now,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteMFARepository) UseRecoveryCode(
+	ctx context.Context,
+	userID, code string,
+) error {
+	op := "repository.MFARepository.UseRecoveryCode"
+
+	query := `
+		DELETE FROM recovery_codes
+		WHERE user_id = ? AND code_hash = ?
+	`
+
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		userID,
+		pkg.HashToken(pkg.NormalizeRecoveryCode(code)),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrRecoveryCodeNotFound)
+	}
+
+	return nil
+}
+
+// CreateChallenge stores a new challenge and drops expired ones.
+func (r *SqliteMFARepository) CreateChallenge(
+	ctx context.Context,
+	challenge *repository.MFAChallenge,
+) error {
+	op := "repository.MFARepository.CreateChallenge"
+
+	if challenge.ID == "" {
+		challenge.ID = uuid.New().String()
+	}
+	challenge.TokenHash = pkg.HashToken(challenge.Token)
+
+	now := time.Now()
+	challenge.CreatedAt = now
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM mfa_challenges WHERE expires_at <= ?`,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+		INSERT INTO mfa_challenges (
+			id, token_hash, user_id, device, expires_at, created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		challenge.ID,
+		challenge.TokenHash,
+		challenge.UserID,
+		challenge.Device,
+		challenge.ExpiresAt,
+		challenge.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteMFARepository) ChallengeByToken(
+	ctx context.Context,
+	token string,
+) (*repository.MFAChallenge, error) {
+	op := "repository.MFARepository.ChallengeByToken"
+	challenge := new(repository.MFAChallenge)
+
+	query := `
+		SELECT id, token_hash, user_id, device, expires_at, created_at
+		FROM mfa_challenges
+		WHERE token_hash = ?
+	`
+
+	err := r.db.GetContext(ctx, challenge, query, pkg.HashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrChallengeNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return challenge, nil
+}
+
+func (r *SqliteMFARepository) DeleteChallenge(
+	ctx context.Context,
+	id string,
+) error {
+	op := "repository.MFARepository.DeleteChallenge"
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM mfa_challenges WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrChallengeNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SqlitePasswordResetRepository struct {
+	db *sqlx.DB
+}
+
+func NewPasswordResetRepository(db *sqlx.DB) *SqlitePasswordResetRepository {
+	return &SqlitePasswordResetRepository{db: db}
+}
+
+func (r *SqlitePasswordResetRepository) CreateResetToken(
+	ctx context.Context,
+	token *repository.PasswordResetToken,
+) error {
+	op := "repository.PasswordResetRepository.CreateResetToken"
+
+	if token.ID == "" {
+		token.ID = uuid.New().String()
+	}
+	token.TokenHash = pkg.HashToken(token.Token)
+
+	now := time.Now()
+	token.CreatedAt = now
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM password_reset_tokens WHERE user_id = ? OR expires_at <= ?`,
+		token.UserID,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+		INSERT INTO password_reset_tokens (
+			id, token_hash, user_id, expires_at, created_at
+		)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		token.ID,
+		token.TokenHash,
+		token.UserID,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqlitePasswordResetRepository) ResetTokenByToken(
+	ctx context.Context,
+	token string,
+) (*repository.PasswordResetToken, error) {
+	op := "repository.PasswordResetRepository.ResetTokenByToken"
+	resetToken := new(repository.PasswordResetToken)
+
+	query := `
+		SELECT id, token_hash, user_id, expires_at, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = ?
+	`
+
+	err := r.db.GetContext(ctx, resetToken, query, pkg.HashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrResetTokenNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resetToken, nil
+}
+
+func (r *SqlitePasswordResetRepository) DeleteResetToken(
+	ctx context.Context,
+	id string,
+) error {
+	op := "repository.PasswordResetRepository.DeleteResetToken"
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM password_reset_tokens WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrResetTokenNotFound)
+	}
+
+	return nil
+}
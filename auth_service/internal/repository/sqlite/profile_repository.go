@@ -0,0 +1,205 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteProfileRepository struct {
+	db *sqlx.DB
+}
+
+func NewProfileRepository(db *sqlx.DB) *SqliteProfileRepository {
+	return &SqliteProfileRepository{db: db}
+}
+
+// profileColumns selects a profile joined with its user, so users who
+// never saved a profile still get one with empty fields.
+const profileColumns = `
+	u.id AS user_id, u.username,
+	COALESCE(p.display_name, '') AS display_name,
+	COALESCE(p.bio, '') AS bio,
+	COALESCE(p.avatar_attachment_id, '') AS avatar_attachment_id,
+	COALESCE(p.status_text, '') AS status_text,
+	p.updated_at
+`
+
+func (r *SqliteProfileRepository) ProfileByUserID(
+	ctx context.Context,
+	userID string,
+) (*repository.Profile, error) {
+	op := "repository.ProfileRepository.ProfileByUserID"
+	profile := new(repository.Profile)
+
+	query := `
+		SELECT ` + profileColumns + `
+		FROM users u
+		LEFT JOIN profiles p ON p.user_id = u.id
+		WHERE u.id = ? AND u.deleted_at IS NULL
+	`
+
+	err := r.db.GetContext(ctx, profile, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return profile, nil
+}
+
+func (r *SqliteProfileRepository) ProfilesByUserIDs(
+	ctx context.Context,
+	userIDs []string,
+) ([]*repository.Profile, error) {
+	op := "repository.ProfileRepository.ProfilesByUserIDs"
+
+	profiles := make([]*repository.Profile, 0, len(userIDs))
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+
+	placeholders, args := inArgs(userIDs)
+	query := `
+		SELECT ` + profileColumns + `
+		FROM users u
+		LEFT JOIN profiles p ON p.user_id = u.id
+		WHERE u.id IN (` + placeholders + `) AND u.deleted_at IS NULL
+	`
+
+	if err := r.db.SelectContext(ctx, &profiles, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return profiles, nil
+}
+
+func (r *SqliteProfileRepository) SaveProfile(
+	ctx context.Context,
+	profile *repository.Profile,
+) error {
+	op := "repository.ProfileRepository.SaveProfile"
+
+	now := time.Now()
+	profile.UpdatedAt = &now
+
+	query := `
+		INSERT INTO profiles (
+			user_id, display_name, normalized_display_name, bio,
+			avatar_attachment_id, status_text, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			display_name = excluded.display_name,
+			normalized_display_name = excluded.normalized_display_name,
+			bio = excluded.bio,
+			avatar_attachment_id = excluded.avatar_attachment_id,
+			status_text = excluded.status_text,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		profile.UserID,
+		profile.DisplayName,
+		pkg.FoldText(profile.DisplayName),
+		profile.Bio,
+		profile.AvatarAttachmentID,
+		profile.StatusText,
+		profile.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SearchProfiles ranks matches in SQL so that keyset pagination over
+// (rank, name_length, normalized_username, user_id) stays stable.
+func (r *SqliteProfileRepository) SearchProfiles(
+	ctx context.Context,
+	query repository.ProfileSearchQuery,
+) ([]*repository.ProfileMatch, error) {
+	op := "repository.ProfileRepository.SearchProfiles"
+
+	escaped := escapeLike(query.Query)
+
+	var fuzzy strings.Builder
+	fuzzy.WriteString("%")
+	for _, char := range query.Query {
+		fuzzy.WriteString(escapeLike(string(char)))
+		fuzzy.WriteString("%")
+	}
+
+	args := []any{
+		query.Query,
+		escaped + "%",
+		escaped + "%",
+		"% " + escaped + "%",
+		"%" + escaped + "%",
+		"%" + escaped + "%",
+		repository.StatusActive,
+		time.Now(),
+		fuzzy.String(),
+		fuzzy.String(),
+	}
+
+	after := ""
+	if query.After != nil {
+		after = "WHERE (rank, name_length, normalized_username, user_id) > (?, ?, ?, ?)"
+		args = append(
+			args,
+			query.After.Rank,
+			query.After.NameLength,
+			query.After.NormalizedUsername,
+			query.After.UserID,
+		)
+	}
+	args = append(args, query.Limit)
+
+	sqlQuery := `
+		SELECT * FROM (
+			SELECT ` + profileColumns + `,
+				u.normalized_username,
+				length(u.normalized_username) AS name_length,
+				CASE
+					WHEN u.normalized_username = ? THEN 0
+					WHEN u.normalized_username LIKE ? ESCAPE '\' THEN 1
+					WHEN p.normalized_display_name LIKE ? ESCAPE '\'
+						OR p.normalized_display_name LIKE ? ESCAPE '\' THEN 2
+					WHEN u.normalized_username LIKE ? ESCAPE '\'
+						OR p.normalized_display_name LIKE ? ESCAPE '\' THEN 3
+					ELSE 4
+				END AS rank
+			FROM users u
+			LEFT JOIN profiles p ON p.user_id = u.id
+			WHERE u.deleted_at IS NULL
+				AND (u.status = ? OR u.status_until <= ?)
+				AND (
+					u.normalized_username LIKE ? ESCAPE '\'
+					OR p.normalized_display_name LIKE ? ESCAPE '\'
+				)
+		)
+		` + after + `
+		ORDER BY rank, name_length, normalized_username, user_id
+		LIMIT ?
+	`
+
+	matches := make([]*repository.ProfileMatch, 0, query.Limit)
+	if err := r.db.SelectContext(ctx, &matches, sqlQuery, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auth.service/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteRevocationRepository struct {
+	db *sqlx.DB
+}
+
+func NewRevocationRepository(db *sqlx.DB) *SqliteRevocationRepository {
+	return &SqliteRevocationRepository{db: db}
+}
+
+func (r *SqliteRevocationRepository) RevokeToken(
+	ctx context.Context,
+	token *repository.RevokedToken,
+) error {
+	op := "repository.RevocationRepository.RevokeToken"
+
+	token.RevokedAt = time.Now()
+
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		token.JTI,
+		token.UserID,
+		token.ExpiresAt,
+		token.RevokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteRevocationRepository) RevokedTokens(
+	ctx context.Context,
+	now time.Time,
+) ([]*repository.RevokedToken, error) {
+	op := "repository.RevocationRepository.RevokedTokens"
+	tokens := make([]*repository.RevokedToken, 0)
+
+	query := `
+		SELECT jti, user_id, expires_at, revoked_at
+		FROM revoked_tokens
+		WHERE expires_at > ?
+	`
+
+	err := r.db.SelectContext(ctx, &tokens, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+func (r *SqliteRevocationRepository) DeleteExpiredTokens(
+	ctx context.Context,
+	now time.Time,
+) error {
+	op := "repository.RevocationRepository.DeleteExpiredTokens"
+
+	query := `
+		DELETE FROM revoked_tokens
+		WHERE expires_at <= ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteRevocationRepository) RevokeUserTokens(
+	ctx context.Context,
+	revocation *repository.UserTokenRevocation,
+) error {
+	op := "repository.RevocationRepository.RevokeUserTokens"
+
+	query := `
+		INSERT INTO user_token_revocations (user_id, revoked_before, kept_jti)
+		VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			revoked_before = excluded.revoked_before,
+			kept_jti = excluded.kept_jti
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		revocation.UserID,
+		revocation.RevokedBefore,
+		revocation.KeptJTI,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteRevocationRepository) KeepUserToken(
+	ctx context.Context,
+	userID, jti string,
+) error {
+	op := "repository.RevocationRepository.KeepUserToken"
+
+	query := `
+		UPDATE user_token_revocations
+		SET kept_jti = ?
+		WHERE user_id = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, jti, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SqliteRevocationRepository) UserTokenRevocations(
+	ctx context.Context,
+	since time.Time,
+) ([]*repository.UserTokenRevocation, error) {
+	op := "repository.RevocationRepository.UserTokenRevocations"
+	revocations := make([]*repository.UserTokenRevocation, 0)
+
+	query := `
+		SELECT user_id, revoked_before, kept_jti
+		FROM user_token_revocations
+		WHERE revoked_before > ?
+	`
+
+	err := r.db.SelectContext(ctx, &revocations, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revocations, nil
+}
@@ -2,10 +2,13 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"auth.service/internal/repository"
+	"auth.service/pkg"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -27,14 +30,23 @@ func (r *SqliteSessionRepository) CreateSession(
 	if session.ID == "" {
 		session.ID = uuid.New().String()
 	}
+	if session.FamilyID == "" {
+		session.FamilyID = session.ID
+	}
+	session.RefreshTokenHash = pkg.HashToken(session.RefreshToken)
 
 	now := time.Now()
 	session.CreatedAt = now
-	session.ExpiresAt = now.Add(24 * time.Hour)
+	if session.ExpiresAt.IsZero() {
+		session.ExpiresAt = now.Add(24 * time.Hour)
+	}
 
 	query := `
-		INSERT INTO sessions (id, user_id, refresh_token, expires_at, created_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO sessions (
+			id, user_id, refresh_token_hash, device, family_id,
+			access_token_jti, expires_at, created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.ExecContext(
@@ -42,7 +54,10 @@ func (r *SqliteSessionRepository) CreateSession(
 		query,
 		session.ID,
 		session.UserID,
-		session.RefreshToken,
+		session.RefreshTokenHash,
+		session.Device,
+		session.FamilyID,
+		session.AccessTokenJTI,
 		session.ExpiresAt,
 		session.CreatedAt,
 	)
@@ -56,23 +71,105 @@ func (r *SqliteSessionRepository) CreateSession(
 func (r *SqliteSessionRepository) GetByRefreshToken(
 	ctx context.Context,
 	refreshToken string,
+) (*repository.Session, error) {
+	op := "repository.SessionRepository.GetByRefreshToken"
+	session := new(repository.Session)
+
+	query := `
+		SELECT id, user_id, refresh_token_hash, device, family_id, rotated_at,
+			access_token_jti, expires_at, created_at
+		FROM sessions
+		WHERE refresh_token_hash = ?
+	`
+	err := r.db.GetContext(ctx, session, query, pkg.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrSessionNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+func (r *SqliteSessionRepository) SessionByID(
+	ctx context.Context,
+	id string,
 ) (*repository.Session, error) {
 	op := "repository.SessionRepository.SessionByID"
 	session := new(repository.Session)
 
 	query := `
-		SELECT id, user_id, refresh_token, expires_at, created_at
+		SELECT id, user_id, refresh_token_hash, device, family_id, rotated_at,
+			access_token_jti, expires_at, created_at
 		FROM sessions
-		WHERE refresh_token = ?
+		WHERE id = ?
 	`
-	err := r.db.GetContext(ctx, session, query, refreshToken)
+	err := r.db.GetContext(ctx, session, query, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrSessionNotFound)
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return session, nil
 }
 
+func (r *SqliteSessionRepository) SessionsByUserID(
+	ctx context.Context,
+	userID string,
+) ([]*repository.Session, error) {
+	op := "repository.SessionRepository.SessionsByUserID"
+	sessions := make([]*repository.Session, 0)
+
+	query := `
+		SELECT id, user_id, refresh_token_hash, device, family_id, rotated_at,
+			access_token_jti, expires_at, created_at
+		FROM sessions
+		WHERE user_id = ? AND rotated_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC
+	`
+	err := r.db.SelectContext(ctx, &sessions, query, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// MarkRotated retires a session whose refresh token has been exchanged.
+// Only the first call for a session succeeds; later ones report
+// ErrSessionNotFound, which makes concurrent refreshes look like reuse.
+// The row is removed by DeleteExpiredFamilies once its family expires.
+func (r *SqliteSessionRepository) MarkRotated(
+	ctx context.Context,
+	id string,
+) error {
+	op := "repository.SessionRepository.MarkRotated"
+
+	query := `
+		UPDATE sessions
+		SET rotated_at = ?
+		WHERE id = ? AND rotated_at IS NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrSessionNotFound)
+	}
+
+	return nil
+}
+
 func (r *SqliteSessionRepository) DeleteSession(
 	ctx context.Context,
 	id string,
@@ -94,7 +191,7 @@ func (r *SqliteSessionRepository) DeleteSession(
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%s: %s", op, "session not found")
+		return fmt.Errorf("%s: %w", op, repository.ErrSessionNotFound)
 	}
 	return nil
 }
@@ -120,8 +217,63 @@ func (r *SqliteSessionRepository) DeleteByUserID(
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%s: %s", op, "sessions not found")
+		return fmt.Errorf("%s: %w", op, repository.ErrSessionNotFound)
 	}
 
 	return nil
 }
+
+func (r *SqliteSessionRepository) DeleteByFamilyID(
+	ctx context.Context,
+	familyID string,
+) ([]*repository.Session, error) {
+	op := "repository.SessionRepository.DeleteByFamilyID"
+	sessions := make([]*repository.Session, 0)
+
+	// RETURNING reports exactly the rows deleted, including a session a
+	// concurrent refresh added to the family a moment earlier.
+	query := `
+		DELETE FROM sessions
+		WHERE family_id = ?
+		RETURNING id, user_id, refresh_token_hash, device, family_id,
+			rotated_at, access_token_jti, expires_at, created_at
+	`
+
+	if err := r.db.SelectContext(ctx, &sessions, query, familyID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, repository.ErrSessionNotFound)
+	}
+
+	return sessions, nil
+}
+
+func (r *SqliteSessionRepository) DeleteExpiredFamilies(
+	ctx context.Context,
+	now time.Time,
+) (int64, error) {
+	op := "repository.SessionRepository.DeleteExpiredFamilies"
+	query := `
+		DELETE FROM sessions
+		WHERE family_id IN (
+			SELECT family_id
+			FROM sessions
+			GROUP BY family_id
+			HAVING MAX(expires_at) <= ?
+		)
+	`
+
+	res, err := r.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
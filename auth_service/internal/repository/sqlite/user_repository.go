@@ -2,12 +2,17 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"auth.service/internal/repository"
+	"auth.service/pkg"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
 )
 
 type SqliteUserRepository struct {
@@ -25,13 +30,24 @@ func (r *SqliteUserRepository) CreateUser(ctx context.Context, user *repository.
 		user.ID = uuid.New().String()
 	}
 
+	user.NormalizedUsername = pkg.NormalizeUsername(user.Username)
+	if user.Role == "" {
+		user.Role = repository.RoleUser
+	}
+	if user.Status == "" {
+		user.Status = repository.StatusActive
+	}
+
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
 
 	query := `
-		INSERT INTO users (id, username, password_hash, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (
+			id, username, normalized_username, password_hash, role, status,
+			status_reason, status_until, created_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.ExecContext(
@@ -39,12 +55,20 @@ func (r *SqliteUserRepository) CreateUser(ctx context.Context, user *repository.
 		query,
 		user.ID,
 		user.Username,
+		user.NormalizedUsername,
 		user.PasswordHash,
+		user.Role,
+		user.Status,
+		user.StatusReason,
+		user.StatusUntil,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, repository.ErrUserAlreadyExists)
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
@@ -58,13 +82,18 @@ func (r *SqliteUserRepository) UserByID(
 	user := new(repository.User)
 
 	query := `
-		SELECT id, username, password_hash, created_at, updated_at
+		SELECT
+			id, username, normalized_username, password_hash, role, status,
+			status_reason, status_until, created_at, updated_at, deleted_at
 		FROM users
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	err := r.db.GetContext(ctx, user, query, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -79,12 +108,14 @@ func (r *SqliteUserRepository) UserByUsername(
 	user := new(repository.User)
 
 	query := `
-		SELECT id, username, password_hash, created_at, updated_at
+		SELECT
+			id, username, normalized_username, password_hash, role, status,
+			status_reason, status_until, created_at, updated_at, deleted_at
 		FROM users
-		WHERE username = ?
+		WHERE normalized_username = ? AND deleted_at IS NULL
 	`
 
-	err := r.db.GetContext(ctx, user, query, username)
+	err := r.db.GetContext(ctx, user, query, pkg.NormalizeUsername(username))
 	if err != nil {
 		switch {
 		case err.Error() == "sql: no rows in result set":
@@ -103,22 +134,29 @@ func (r *SqliteUserRepository) UpdateUser(
 ) error {
 	op := "repository.UserRepository.UpdateUser"
 
+	user.NormalizedUsername = pkg.NormalizeUsername(user.Username)
 	user.UpdatedAt = time.Now()
 	query := `
 		UPDATE users
-		SET username = ?, password_hash = ?, updated_at = ?
-		WHERE id = ?
+		SET
+			username = ?, normalized_username = ?, password_hash = ?,
+			updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	res, err := r.db.ExecContext(
 		ctx,
 		query,
 		user.Username,
+		user.NormalizedUsername,
 		user.PasswordHash,
 		user.UpdatedAt,
 		user.ID,
 	)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, repository.ErrUserAlreadyExists)
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -141,11 +179,12 @@ func (r *SqliteUserRepository) DeleteUser(
 	op := "repository.UserRepository.DeleteUser"
 
 	query := `
-		DELETE FROM users
-		WHERE id = ?
+		UPDATE users
+		SET deleted_at = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	res, err := r.db.ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -156,8 +195,301 @@ func (r *SqliteUserRepository) DeleteUser(
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%s: %s", op, "user not found")
+		return fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
 	}
 
 	return nil
 }
+
+func (r *SqliteUserRepository) DeletedUserByUsername(
+	ctx context.Context,
+	username string,
+) (*repository.User, error) {
+	op := "repository.UserRepository.DeletedUserByUsername"
+	user := new(repository.User)
+
+	query := `
+		SELECT
+			id, username, normalized_username, password_hash, role, status,
+			status_reason, status_until, created_at, updated_at, deleted_at
+		FROM users
+		WHERE normalized_username = ? AND deleted_at IS NOT NULL
+	`
+
+	err := r.db.GetContext(ctx, user, query, pkg.NormalizeUsername(username))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (r *SqliteUserRepository) RestoreUser(
+	ctx context.Context,
+	id string,
+	deletedAfter time.Time,
+) error {
+	op := "repository.UserRepository.RestoreUser"
+
+	query := `
+		UPDATE users
+		SET deleted_at = NULL, updated_at = ?
+		WHERE id = ? AND deleted_at > ?
+	`
+
+	res, err := r.db.ExecContext(ctx, query, time.Now(), id, deletedAfter)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers removes the rows that reference the purged users
+// explicitly, since SQLite does not enforce ON DELETE CASCADE unless
+// foreign keys are enabled on the connection.
+func (r *SqliteUserRepository) PurgeDeletedUsers(
+	ctx context.Context,
+	deletedBefore time.Time,
+) (int64, error) {
+	op := "repository.UserRepository.PurgeDeletedUsers"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	dependents := []string{
+		"sessions",
+		"revoked_tokens",
+		"user_token_revocations",
+		"totp_secrets",
+		"recovery_codes",
+		"mfa_challenges",
+		"password_reset_tokens",
+		"profiles",
+	}
+	for _, table := range dependents {
+		query := fmt.Sprintf(`
+			DELETE FROM %s
+			WHERE user_id IN (
+				SELECT id FROM users
+				WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+			)
+		`, table)
+
+		if _, err := tx.ExecContext(ctx, query, deletedBefore); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at <= ?`,
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purged, nil
+}
+
+func (r *SqliteUserRepository) SetUserStatus(
+	ctx context.Context,
+	id, status, reason string,
+	until *time.Time,
+) error {
+	op := "repository.UserRepository.SetUserStatus"
+
+	query := `
+		UPDATE users
+		SET status = ?, status_reason = ?, status_until = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	res, err := r.db.ExecContext(ctx, query, status, reason, until, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+func (r *SqliteUserRepository) UsersByIDs(
+	ctx context.Context,
+	ids []string,
+) ([]*repository.User, error) {
+	op := "repository.UserRepository.UsersByIDs"
+
+	users := make([]*repository.User, 0, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	placeholders, args := inArgs(ids)
+	query := `
+		SELECT
+			id, username, normalized_username, password_hash, role, status,
+			status_reason, status_until, created_at, updated_at, deleted_at
+		FROM users
+		WHERE id IN (` + placeholders + `) AND deleted_at IS NULL
+	`
+
+	if err := r.db.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+func (r *SqliteUserRepository) UsersByUsernames(
+	ctx context.Context,
+	usernames []string,
+) ([]*repository.User, error) {
+	op := "repository.UserRepository.UsersByUsernames"
+
+	users := make([]*repository.User, 0, len(usernames))
+	if len(usernames) == 0 {
+		return users, nil
+	}
+
+	normalized := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		normalized = append(normalized, pkg.NormalizeUsername(username))
+	}
+
+	placeholders, args := inArgs(normalized)
+	query := `
+		SELECT
+			id, username, normalized_username, password_hash, role, status,
+			status_reason, status_until, created_at, updated_at, deleted_at
+		FROM users
+		WHERE normalized_username IN (` + placeholders + `) AND deleted_at IS NULL
+	`
+
+	if err := r.db.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// ListUsers returns one page of users using keyset pagination: the page
+// starts right after query.After in (order column, id) order, so pages
+// stay stable while users are created or deleted.
+func (r *SqliteUserRepository) ListUsers(
+	ctx context.Context,
+	query repository.UserListQuery,
+) ([]*repository.User, error) {
+	op := "repository.UserRepository.ListUsers"
+
+	var column string
+	switch query.OrderBy {
+	case repository.UserOrderUsername, "":
+		column = "normalized_username"
+	case repository.UserOrderCreatedAt:
+		column = "created_at"
+	default:
+		return nil, fmt.Errorf("%s: unknown order %q", op, query.OrderBy)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if query.UsernamePrefix != "" {
+		conditions = append(conditions, `normalized_username LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(pkg.NormalizeUsername(query.UsernamePrefix))+"%")
+	}
+
+	if query.After != nil {
+		var value any = query.After.NormalizedUsername
+		if column == "created_at" {
+			value = query.After.CreatedAt
+		}
+
+		conditions = append(conditions, fmt.Sprintf(
+			"(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))",
+			column,
+			comparison,
+		))
+		args = append(args, value, value, query.After.ID)
+	}
+
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			id, username, normalized_username, password_hash, role, status,
+			status_reason, status_until, created_at, updated_at, deleted_at
+		FROM users
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT ?
+	`, where, column, direction, direction)
+	args = append(args, query.Limit)
+
+	users := make([]*repository.User, 0, query.Limit)
+	if err := r.db.SelectContext(ctx, &users, sqlQuery, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// inArgs returns the "?, ?, ..." list and arguments for an IN clause.
+func inArgs(values []string) (string, []any) {
+	args := make([]any, 0, len(values))
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "), args
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
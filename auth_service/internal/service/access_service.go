@@ -2,15 +2,29 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth.service/internal/keys"
+	"auth.service/internal/repository"
 )
 
 type AccessServiceImpl struct {
 	authService AuthService
+	userRepo    repository.UserRepository
+	keySet      *keys.KeySet
 }
 
-func NewAccessService(authService AuthService) *AccessServiceImpl {
+func NewAccessService(
+	authService AuthService,
+	userRepo repository.UserRepository,
+	keySet *keys.KeySet,
+) *AccessServiceImpl {
 	return &AccessServiceImpl{
 		authService: authService,
+		userRepo:    userRepo,
+		keySet:      keySet,
 	}
 }
 
@@ -26,5 +40,23 @@ func (s *AccessServiceImpl) Check(
 		return false, "", ErrInvalidToken
 	}
 
+	// Disabling an account revokes its tokens, but the account is
+	// checked as well so restrictions set directly in the database
+	// still apply.
+	user, err := s.userRepo.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return false, "", ErrInvalidToken
+		}
+		return false, "", fmt.Errorf("AccessService.Check: %w", err)
+	}
+	if err := checkAccountStatus(user, time.Now()); err != nil {
+		return false, "", err
+	}
+
 	return true, claims.UserID, nil
 }
+
+func (s *AccessServiceImpl) PublicKeys(ctx context.Context) []keys.JWK {
+	return s.keySet.PublicKeys()
+}
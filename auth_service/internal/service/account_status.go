@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth.service/internal/repository"
+)
+
+const (
+	StatusActive   = repository.StatusActive
+	StatusDisabled = repository.StatusDisabled
+	StatusBanned   = repository.StatusBanned
+)
+
+var ErrInvalidStatus = errors.New("invalid account status")
+
+// AccountInactiveError is returned when a disabled or banned account
+// tries to log in or refresh its tokens.
+type AccountInactiveError struct {
+	Status string
+	Reason string
+	// Until is when the restriction ends, or nil if it is permanent.
+	Until *time.Time
+}
+
+func (e *AccountInactiveError) Error() string {
+	if e.Until != nil {
+		return fmt.Sprintf("account %s until %s", e.Status, e.Until.Format(time.RFC3339))
+	}
+
+	return "account " + e.Status
+}
+
+// checkAccountStatus returns an *AccountInactiveError unless the user
+// is active. A restriction whose until time has passed no longer
+// applies, so temporary bans lift themselves.
+func checkAccountStatus(user *repository.User, now time.Time) error {
+	if user.Status == StatusActive || user.Status == "" {
+		return nil
+	}
+	if user.StatusUntil != nil && !now.Before(*user.StatusUntil) {
+		return nil
+	}
+
+	return &AccountInactiveError{
+		Status: user.Status,
+		Reason: user.StatusReason,
+		Until:  user.StatusUntil,
+	}
+}
+
+// SetUserStatus changes the account status. Disabling or banning an
+// account ends all of its sessions and revokes its access tokens.
+func (s *UserServiceImpl) SetUserStatus(
+	ctx context.Context,
+	userID, status, reason string,
+	until *time.Time,
+) error {
+	op := "UserService.SetUserStatus"
+
+	switch status {
+	case StatusActive:
+		reason, until = "", nil
+	case StatusDisabled, StatusBanned:
+	default:
+		return ErrInvalidStatus
+	}
+
+	if err := s.userRepo.SetUserStatus(ctx, userID, status, reason, until); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status != StatusActive {
+		if err := s.revokeAll(ctx, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
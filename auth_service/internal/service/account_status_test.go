@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth.service/internal/repository"
+)
+
+func TestCheckAccountStatus(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		user   repository.User
+		active bool
+	}{
+		{"active", repository.User{Status: StatusActive}, true},
+		{"no status", repository.User{}, true},
+		{"disabled", repository.User{Status: StatusDisabled}, false},
+		{"banned until later", repository.User{Status: StatusBanned, StatusUntil: &future}, false},
+		{"ban ended", repository.User{Status: StatusBanned, StatusUntil: &past}, true},
+		{"ban ends now", repository.User{Status: StatusBanned, StatusUntil: &now}, true},
+	}
+
+	for _, tt := range tests {
+		err := checkAccountStatus(&tt.user, now)
+		if active := err == nil; active != tt.active {
+			t.Errorf("%s: checkAccountStatus = %v, want active %v", tt.name, err, tt.active)
+		}
+	}
+}
+
+func TestDisabledUserCannotLogIn(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	if err := s.users.SetUserStatus(ctx, userID, StatusDisabled, "spam", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{})
+	var inactive *AccountInactiveError
+	if !errors.As(err, &inactive) {
+		t.Fatalf("Login = %v, want *AccountInactiveError", err)
+	}
+	if inactive.Status != StatusDisabled || inactive.Reason != "spam" || inactive.Until != nil {
+		t.Fatalf("unexpected error %+v", inactive)
+	}
+
+	// The status is not revealed without the password.
+	if _, err := s.auth.Login(ctx, "alice", "wrong", ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login with a wrong password = %v, want ErrInvalidCredentials", err)
+	}
+
+	// Existing tokens stop working.
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("ValidateToken = %v, want ErrRevokedToken", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens = %v, want ErrInvalidToken", err)
+	}
+
+	if err := s.users.SetUserStatus(ctx, userID, StatusActive, "ignored", nil); err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Status != StatusActive || user.StatusReason != "" {
+		t.Fatalf("reactivated user has status %q, reason %q", user.Status, user.StatusReason)
+	}
+	s.login(t, "alice", "laptop")
+}
+
+func TestTemporaryBanLiftsItself(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+
+	until := time.Now().Add(time.Hour)
+	if err := s.users.SetUserStatus(ctx, userID, StatusBanned, "", &until); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{})
+	var inactive *AccountInactiveError
+	if !errors.As(err, &inactive) || inactive.Until == nil || !inactive.Until.Equal(until) {
+		t.Fatalf("Login = %v, want *AccountInactiveError until %s", err, until)
+	}
+
+	if _, err := s.db.Exec(`UPDATE users SET status_until = ?`, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	s.login(t, "alice", "laptop")
+}
+
+func TestStatusSetInTheDatabaseIsEnforced(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	// Bypass SetUserStatus, which would also revoke the tokens.
+	if _, err := s.db.Exec(`UPDATE users SET status = ?`, StatusBanned); err != nil {
+		t.Fatal(err)
+	}
+
+	var inactive *AccountInactiveError
+	if _, _, err := s.access.Check(ctx, tokens.AccessToken); !errors.As(err, &inactive) {
+		t.Fatalf("Check = %v, want *AccountInactiveError", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.As(err, &inactive) {
+		t.Fatalf("RefreshTokens = %v, want *AccountInactiveError", err)
+	}
+	// The refused family is gone.
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("second RefreshTokens = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestSetUserStatusErrors(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+
+	if err := s.users.SetUserStatus(ctx, userID, "suspended", "", nil); !errors.Is(err, ErrInvalidStatus) {
+		t.Fatalf("SetUserStatus(suspended) = %v, want ErrInvalidStatus", err)
+	}
+	if err := s.users.SetUserStatus(ctx, "missing", StatusDisabled, "", nil); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("SetUserStatus of a missing user = %v, want ErrUserNotFound", err)
+	}
+}
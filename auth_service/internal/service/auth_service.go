@@ -2,11 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"log"
 	"time"
 
 	"auth.service/internal/config"
+	"auth.service/internal/keys"
 	"auth.service/internal/repository"
 	"auth.service/pkg"
 	"github.com/golang-jwt/jwt/v5"
@@ -16,20 +18,38 @@ import (
 type CustomClaims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type AuthServiceImpl struct {
 	userRepo    repository.UserRepository
 	sessionRepo repository.SessionRepository
+	mfaRepo     repository.MFARepository
+	revocations RevocationService
+	limiter     LoginLimiter
+	hasher      pkg.PasswordHasher
+	mfa         MFAService
+	users       UserService
+	keySet      *keys.KeySet
 	jwtSecret   []byte
 	accessTTL   time.Duration
 	refreshTTL  time.Duration
+	mfaTTL      time.Duration
+
+	cleanupInterval time.Duration
 }
 
 func NewAuthService(
 	userRepo repository.UserRepository,
 	sessionRepo repository.SessionRepository,
+	mfaRepo repository.MFARepository,
+	revocations RevocationService,
+	limiter LoginLimiter,
+	hasher pkg.PasswordHasher,
+	mfa MFAService,
+	users UserService,
+	keySet *keys.KeySet,
 	jwtSecret []byte,
 	accessTTL time.Duration,
 	refreshTTL time.Duration,
@@ -37,47 +57,335 @@ func NewAuthService(
 	return &AuthServiceImpl{
 		userRepo:    userRepo,
 		sessionRepo: sessionRepo,
+		mfaRepo:     mfaRepo,
+		revocations: revocations,
+		limiter:     limiter,
+		hasher:      hasher,
+		mfa:         mfa,
+		users:       users,
+		keySet:      keySet,
 		jwtSecret:   []byte(config.Env.JWTSecret),
-		accessTTL:   parseDuration(config.Env.AccessTokenTTL),
-		refreshTTL:  parseDuration(config.Env.RefreshTokenTTL),
+		accessTTL:   parseDuration(config.Env.AccessTokenTTL, 15*time.Minute),
+		refreshTTL:  parseDuration(config.Env.RefreshTokenTTL, 24*time.Hour),
+		mfaTTL:      parseDuration(config.Env.MFAChallengeTTL, 5*time.Minute),
+
+		cleanupInterval: parseDuration(config.Env.SessionCleanupInterval, time.Hour),
 	}
 }
 
 func (s *AuthServiceImpl) Login(
 	ctx context.Context,
 	username, password string,
-) (*TokenPair, error) {
+	client ClientInfo,
+) (*LoginResult, error) {
 	op := "AuthService.Login"
 
+	limiterKeys := []string{UsernameLimiterKey(username)}
+	if client.IP != "" {
+		limiterKeys = append(limiterKeys, IPLimiterKey(client.IP))
+	}
+
+	if err := s.limiter.Allow(ctx, limiterKeys...); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.UserByUsername(ctx, username)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	if user == nil {
-		return nil, ErrInvalidToken
-	}
 
 	if !pkg.CheckPasswordHash(password, user.PasswordHash) {
 		return nil, ErrInvalidCredentials
 	}
 
+	if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The status is only revealed to callers who know the password.
+	if err := checkAccountStatus(user, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(ctx, user, password)
+	}
+
+	mfaEnabled, err := s.mfa.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if mfaEnabled {
+		return s.createChallenge(ctx, user.ID, client.Device)
+	}
+
+	u := &User{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+	}
+
+	tokenPair, err := s.createTokens(ctx, u, client.Device, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{UserID: user.ID, Tokens: tokenPair}, nil
+}
+
+// createChallenge issues the short-lived token that stands for a
+// correct password while the second factor is pending.
+func (s *AuthServiceImpl) createChallenge(
+	ctx context.Context,
+	userID, device string,
+) (*LoginResult, error) {
+	op := "AuthService.createChallenge"
+
+	challenge := &repository.MFAChallenge{
+		Token:     uuid.New().String(),
+		UserID:    userID,
+		Device:    device,
+		ExpiresAt: time.Now().Add(s.mfaTTL),
+	}
+
+	if err := s.mfaRepo.CreateChallenge(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &LoginResult{UserID: userID, MFAToken: challenge.Token}, nil
+}
+
+// VerifyMFA completes a login started with a correct password. Wrong
+// codes count against the same limiter as wrong passwords, keyed by
+// user ID, and each challenge can be redeemed once.
+func (s *AuthServiceImpl) VerifyMFA(
+	ctx context.Context,
+	mfaToken, code string,
+	client ClientInfo,
+) (*TokenPair, error) {
+	op := "AuthService.VerifyMFA"
+
+	challenge, err := s.mfaRepo.ChallengeByToken(ctx, mfaToken)
+	if err != nil {
+		if errors.Is(err, repository.ErrChallengeNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if time.Now().After(challenge.ExpiresAt) {
+		err := s.mfaRepo.DeleteChallenge(ctx, challenge.ID)
+		if err != nil && !errors.Is(err, repository.ErrChallengeNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, ErrExpiredToken
+	}
+
+	limiterKeys := mfaLimiterKeys(challenge.UserID, client)
+	if err := s.limiter.Allow(ctx, limiterKeys...); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfa.VerifyCode(ctx, challenge.UserID, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.DeleteChallenge(ctx, challenge.ID); err != nil {
+		if errors.Is(err, repository.ErrChallengeNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.userRepo.UserByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkAccountStatus(user, time.Now()); err != nil {
+		return nil, err
+	}
+
 	u := &User{
 		ID:       user.ID,
 		Username: user.Username,
+		Role:     user.Role,
+	}
+
+	return s.createTokens(ctx, u, challenge.Device, "")
+}
+
+// rehashPassword replaces an outdated password hash while the plaintext
+// is at hand. Failures are only logged: the login itself succeeded.
+func (s *AuthServiceImpl) rehashPassword(
+	ctx context.Context,
+	user *repository.User,
+	password string,
+) {
+	op := "AuthService.rehashPassword"
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return
+	}
+
+	user.PasswordHash = hash
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+}
+
+// ChangePassword changes the password of an authenticated user. Every
+// existing session and access token is revoked, and the caller gets a
+// fresh token pair so it stays logged in. Wrong current passwords are
+// throttled like failed logins.
+//
+// The new access token can carry the same iat second as the revocation,
+// so it is exempted from it explicitly.
+func (s *AuthServiceImpl) ChangePassword(
+	ctx context.Context,
+	userID, oldPassword, newPassword string,
+	client ClientInfo,
+) (*TokenPair, error) {
+	op := "AuthService.ChangePassword"
+
+	user, err := s.userRepo.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	limiterKeys := []string{UsernameLimiterKey(user.Username)}
+	if client.IP != "" {
+		limiterKeys = append(limiterKeys, IPLimiterKey(client.IP))
+	}
+
+	if err := s.limiter.Allow(ctx, limiterKeys...); err != nil {
+		return nil, err
+	}
+
+	err = s.users.ChangePassword(ctx, userID, oldPassword, newPassword)
+	if err != nil {
+		// A rejected new password means the current one was right.
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jti := uuid.New().String()
+	if err := s.revocations.KeepToken(ctx, user.ID, jti); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	u := &User{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+	}
+
+	return s.issueTokens(ctx, u, client.Device, "", jti)
+}
+
+// RestoreUser restores a deleted account. The password check is
+// throttled like a login, so it cannot be used to guess passwords.
+func (s *AuthServiceImpl) RestoreUser(
+	ctx context.Context,
+	username, password string,
+	client ClientInfo,
+) (string, error) {
+	op := "AuthService.RestoreUser"
+
+	limiterKeys := []string{UsernameLimiterKey(username)}
+	if client.IP != "" {
+		limiterKeys = append(limiterKeys, IPLimiterKey(client.IP))
+	}
+
+	if err := s.limiter.Allow(ctx, limiterKeys...); err != nil {
+		return "", err
+	}
+
+	userID, err := s.users.RestoreUser(ctx, username, password)
+	if err != nil {
+		// The restore window is only checked after the password.
+		if errors.Is(err, ErrRestoreWindowExpired) {
+			if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+				return "", fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		return "", err
+	}
+
+	if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// RequestPasswordReset sends a reset token like UserService does, but
+// throttled per username and client IP so the RPC cannot be used to
+// flood a user with messages. Every request counts: there is no wrong
+// guess to tell apart from a right one, and unknown usernames must not
+// be treated differently.
+func (s *AuthServiceImpl) RequestPasswordReset(
+	ctx context.Context,
+	username string,
+	client ClientInfo,
+) error {
+	limiterKeys := []string{ResetLimiterKey(username)}
+	if client.IP != "" {
+		limiterKeys = append(limiterKeys, IPLimiterKey(client.IP))
 	}
 
-	return s.createTokens(ctx, u)
+	if err := s.limiter.Allow(ctx, limiterKeys...); err != nil {
+		return err
+	}
+
+	return s.users.RequestPasswordReset(ctx, username)
 }
 
+// createTokens issues a token pair and stores its session. An empty
+// familyID starts a new token family.
 func (s *AuthServiceImpl) createTokens(
 	ctx context.Context,
 	user *User,
+	device string,
+	familyID string,
+) (*TokenPair, error) {
+	return s.issueTokens(ctx, user, device, familyID, uuid.New().String())
+}
+
+// issueTokens is createTokens with the ID of the access token chosen by
+// the caller.
+func (s *AuthServiceImpl) issueTokens(
+	ctx context.Context,
+	user *User,
+	device string,
+	familyID string,
+	jti string,
 ) (*TokenPair, error) {
 	op := "AuthService.createTokens"
 
 	now := time.Now()
 
-	accessToken, err := s.generateAccessToken(user, now)
+	accessToken, err := s.generateAccessToken(user, jti, now)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -85,9 +393,12 @@ func (s *AuthServiceImpl) createTokens(
 	refreshToken := uuid.New().String()
 
 	session := &repository.Session{
-		UserID:       user.ID,
-		RefreshToken: refreshToken,
-		ExpiresAt:    now.Add(s.refreshTTL),
+		UserID:         user.ID,
+		RefreshToken:   refreshToken,
+		Device:         device,
+		FamilyID:       familyID,
+		AccessTokenJTI: jti,
+		ExpiresAt:      now.Add(s.refreshTTL),
 	}
 
 	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
@@ -103,22 +414,37 @@ func (s *AuthServiceImpl) createTokens(
 
 func (s *AuthServiceImpl) generateAccessToken(
 	user *User,
+	jti string,
 	now time.Time,
 ) (string, error) {
 	claims := CustomClaims{
 		UserID:   user.ID,
 		Username: user.Username,
+		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "auth.service",
 			Subject:   user.ID,
+			ID:        jti,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(s.jwtSecret)
+	signingKey := s.keySet.Signing()
+	if signingKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signedToken, err := token.SignedString(s.jwtSecret)
+		if err != nil {
+			return "", fmt.Errorf("Unable to create token")
+		}
+
+		return signedToken, nil
+	}
+
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	token.Header["kid"] = signingKey.ID
+	signedToken, err := token.SignedString(signingKey.Key)
 	if err != nil {
 		return "", fmt.Errorf("Unable to create token")
 	}
@@ -126,6 +452,10 @@ func (s *AuthServiceImpl) generateAccessToken(
 	return signedToken, nil
 }
 
+// RefreshTokens rotates a refresh token within its token family.
+// Presenting a token that has already been rotated means it leaked, so
+// the whole family is revoked, as recommended for OAuth 2.1 refresh
+// token rotation.
 func (s *AuthServiceImpl) RefreshTokens(
 	ctx context.Context,
 	refreshToken string,
@@ -134,26 +464,253 @@ func (s *AuthServiceImpl) RefreshTokens(
 
 	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshToken)
 	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, ErrInvalidToken
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if session.RotatedAt != nil {
+		return nil, s.revokeFamily(ctx, session)
+	}
+
 	if time.Now().After(session.ExpiresAt) {
-		_ = s.sessionRepo.DeleteSession(ctx, session.ID)
+		_, err := s.sessionRepo.DeleteByFamilyID(ctx, session.FamilyID)
+		if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, ErrExpiredToken
 	}
 
 	user, err := s.userRepo.UserByID(ctx, session.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	if statusErr := checkAccountStatus(user, time.Now()); statusErr != nil {
+		_, err := s.sessionRepo.DeleteByFamilyID(ctx, session.FamilyID)
+		if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, statusErr
+	}
 
-	_ = s.sessionRepo.DeleteSession(ctx, session.ID)
+	if err := s.sessionRepo.MarkRotated(ctx, session.ID); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, s.revokeFamily(ctx, session)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
 	u := &User{
 		ID:       user.ID,
 		Username: user.Username,
+		Role:     user.Role,
+	}
+
+	return s.createTokens(ctx, u, session.Device, session.FamilyID)
+}
+
+func (s *AuthServiceImpl) revokeFamily(
+	ctx context.Context,
+	session *repository.Session,
+) error {
+	op := "AuthService.revokeFamily"
+
+	log.Printf(
+		"refresh token reuse detected for user %s, revoking token family %s",
+		session.UserID,
+		session.FamilyID,
+	)
+
+	_, err := s.sessionRepo.DeleteByFamilyID(ctx, session.FamilyID)
+	if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ErrTokenReused
+}
+
+// Logout ends the session behind refreshToken and revokes the access
+// tokens issued with it. If the caller also presents its access token,
+// that token is revoked too.
+func (s *AuthServiceImpl) Logout(
+	ctx context.Context,
+	refreshToken, accessToken string,
+) error {
+	op := "AuthService.Logout"
+
+	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	family, err := s.sessionRepo.DeleteByFamilyID(ctx, session.FamilyID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revokeAccessTokens(ctx, family); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if accessToken == "" {
+		return nil
+	}
+
+	claims, err := s.ValidateToken(ctx, accessToken)
+	if err != nil || claims.UserID != session.UserID {
+		return nil
+	}
+
+	if err := s.revocations.RevokeToken(ctx, claims); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *AuthServiceImpl) LogoutAll(
+	ctx context.Context,
+	userID string,
+) error {
+	op := "AuthService.LogoutAll"
+
+	err := s.sessionRepo.DeleteByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revocations.RevokeUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *AuthServiceImpl) ListSessions(
+	ctx context.Context,
+	userID string,
+) ([]*Session, error) {
+	op := "AuthService.ListSessions"
+
+	sessions, err := s.sessionRepo.SessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make([]*Session, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, &Session{
+			ID:        session.ID,
+			Device:    session.Device,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+		})
 	}
 
-	return s.createTokens(ctx, u)
+	return result, nil
+}
+
+// RevokeSession ends a session, e.g. a stolen one, and revokes the
+// access tokens issued with it. Sessions of other users are reported as
+// not found when userID is set, so their IDs cannot be probed.
+func (s *AuthServiceImpl) RevokeSession(
+	ctx context.Context,
+	sessionID, userID string,
+) error {
+	op := "AuthService.RevokeSession"
+
+	session, err := s.sessionRepo.SessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if userID != "" && session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	family, err := s.sessionRepo.DeleteByFamilyID(ctx, session.FamilyID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revokeAccessTokens(ctx, family); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// revokeAccessTokens revokes the access tokens issued with the given
+// sessions that have not expired yet. A session is created right after
+// its access token, so its creation time bounds the token's expiry.
+func (s *AuthServiceImpl) revokeAccessTokens(
+	ctx context.Context,
+	sessions []*repository.Session,
+) error {
+	now := time.Now()
+
+	for _, session := range sessions {
+		expiresAt := session.CreatedAt.Add(s.accessTTL)
+		if session.AccessTokenJTI == "" || !expiresAt.After(now) {
+			continue
+		}
+
+		err := s.revocations.RevokeToken(ctx, &TokenClaims{
+			ID:        session.AccessTokenJTI,
+			UserID:    session.UserID,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *AuthServiceImpl) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	op := "AuthService.DeleteExpiredSessions"
+
+	deleted, err := s.sessionRepo.DeleteExpiredFamilies(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// RunSessionCleanupJob calls DeleteExpiredSessions once at start and
+// then every cleanup interval until ctx is done.
+func (s *AuthServiceImpl) RunSessionCleanupJob(ctx context.Context) {
+	ticker := time.NewTicker(s.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.DeleteExpiredSessions(ctx)
+		if err != nil {
+			log.Printf("failed to delete expired sessions: %v", err)
+		} else if deleted > 0 {
+			log.Printf("deleted %d expired sessions", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func (s *AuthServiceImpl) ValidateToken(
@@ -163,13 +720,12 @@ func (s *AuthServiceImpl) ValidateToken(
 	token, err := jwt.ParseWithClaims(
 		accessToken,
 		&CustomClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
-			}
-
-			return s.jwtSecret, nil
-		},
+		s.verificationKey,
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+			jwt.SigningMethodRS256.Alg(),
+		}),
 	)
 
 	if err != nil {
@@ -181,23 +737,50 @@ func (s *AuthServiceImpl) ValidateToken(
 			return nil, ErrExpiredToken
 		}
 
-		return &TokenClaims{
+		tokenClaims := &TokenClaims{
+			ID:        claims.ID,
 			UserID:    claims.UserID,
 			Username:  claims.Username,
+			Role:      claims.Role,
 			ExpiresAt: claims.ExpiresAt.Time,
-		}, nil
+		}
+		if claims.IssuedAt != nil {
+			tokenClaims.IssuedAt = claims.IssuedAt.Time
+		}
+
+		if s.revocations.IsRevoked(tokenClaims) {
+			return nil, ErrRevokedToken
+		}
+
+		return tokenClaims, nil
 	}
 
 	return nil, ErrInvalidToken
 }
 
-func parseDuration(value string) time.Duration {
-	duration, err := time.ParseDuration(value)
-	if err != nil {
-		if hours, err := strconv.Atoi(value); err != nil {
-			return time.Duration(hours) * time.Hour
+// verificationKey picks the key a token is checked with. HS256 tokens
+// are accepted only while JWT_SECRET_KEY is still configured, which
+// lets tokens issued before switching to asymmetric keys expire
+// naturally.
+func (s *AuthServiceImpl) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(s.jwtSecret) == 0 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
 		}
-		return 15 * time.Minute
+		return s.jwtSecret, nil
+	default:
+		kid, _ := token.Header["kid"].(string)
+		return s.keySet.VerificationKey(kid)
+	}
+}
+
+// parseDuration falls back to defaultValue for durations config.LoadEnv
+// would have rejected, so that a zero interval never reaches a ticker.
+func parseDuration(value string, defaultValue time.Duration) time.Duration {
+	duration, err := config.ParseDuration(value)
+	if err != nil {
+		return defaultValue
 	}
 
 	return duration
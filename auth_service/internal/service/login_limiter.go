@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"auth.service/internal/config"
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+)
+
+const (
+	loginBaseDelay     = time.Second
+	loginResetAfter    = 24 * time.Hour
+	loginPruneInterval = time.Hour
+)
+
+// ThrottledError is returned by Login while a username or client IP is
+// backing off after failed attempts.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many login attempts, retry after %s", e.RetryAfter)
+}
+
+// LoginLimiterImpl throttles failed logins per key, where a key is a
+// username or a client IP. The first freeAttempts failures are free;
+// after that every failure doubles the wait before the next attempt,
+// and reaching lockoutAttempts locks the key for lockoutDuration.
+// State lives in the database so restarts do not reset it.
+//
+// Allow counts each attempt as a failure before the credentials are
+// checked, and Succeed takes it back. Counting up front means parallel
+// attempts cannot all pass before the first failure is recorded.
+type LoginLimiterImpl struct {
+	attemptRepo     repository.LoginAttemptRepository
+	freeAttempts    int
+	lockoutAttempts int
+	lockoutDuration time.Duration
+}
+
+func NewLoginLimiter(
+	attemptRepo repository.LoginAttemptRepository,
+) *LoginLimiterImpl {
+	return &LoginLimiterImpl{
+		attemptRepo:     attemptRepo,
+		freeAttempts:    parseInt(config.Env.LoginFreeAttempts, 3),
+		lockoutAttempts: parseInt(config.Env.LoginLockoutAttempts, 10),
+		lockoutDuration: parseDuration(config.Env.LoginLockoutDuration, 15*time.Minute),
+	}
+}
+
+func UsernameLimiterKey(username string) string {
+	return "user:" + pkg.NormalizeUsername(username)
+}
+
+// ResetLimiterKey is kept apart from UsernameLimiterKey so that
+// requesting resets for a user cannot lock them out of logging in.
+func ResetLimiterKey(username string) string {
+	return "reset:" + pkg.NormalizeUsername(username)
+}
+
+func MFALimiterKey(userID string) string {
+	return "mfa:" + userID
+}
+
+func IPLimiterKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Allow returns a *ThrottledError if any of the keys is blocked, and
+// otherwise counts a failed attempt for every key. Both happen in one
+// transaction, so a blocked client IP does not add failures to the
+// usernames it tries, and a blocked username does not charge the IP.
+func (l *LoginLimiterImpl) Allow(ctx context.Context, keys ...string) error {
+	op := "LoginLimiter.Allow"
+
+	now := time.Now()
+
+	blockedUntil, reserved, err := l.attemptRepo.ReserveAttempts(
+		ctx,
+		keys,
+		now,
+		now.Add(-loginResetAfter),
+		l.delay,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !reserved {
+		return throttled(blockedUntil.Sub(now))
+	}
+
+	return nil
+}
+
+// Succeed takes back the attempt Allow counted. The first key, the
+// account the credentials were checked for, has all of its failures
+// cleared; the others, such as the client IP, keep their earlier ones.
+func (l *LoginLimiterImpl) Succeed(ctx context.Context, keys ...string) error {
+	op := "LoginLimiter.Succeed"
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := l.attemptRepo.DeleteAttempt(ctx, keys[0]); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	for _, key := range keys[1:] {
+		if err := l.attemptRepo.ReleaseAttempt(ctx, key, now, l.delay); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Prune deletes keys whose failures are old enough to be forgotten.
+func (l *LoginLimiterImpl) Prune(ctx context.Context) (int64, error) {
+	op := "LoginLimiter.Prune"
+
+	now := time.Now()
+
+	deleted, err := l.attemptRepo.DeleteStaleAttempts(ctx, now.Add(-loginResetAfter), now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// RunPruneJob calls Prune once at start and then every hour until ctx
+// is done.
+func (l *LoginLimiterImpl) RunPruneJob(ctx context.Context) {
+	ticker := time.NewTicker(loginPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := l.Prune(ctx)
+		if err != nil {
+			log.Printf("failed to prune login attempts: %v", err)
+		} else if deleted > 0 {
+			log.Printf("pruned %d login attempts", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func throttled(retryAfter time.Duration) error {
+	return &ThrottledError{RetryAfter: retryAfter.Round(time.Second)}
+}
+
+func (l *LoginLimiterImpl) delay(failures int) time.Duration {
+	if failures >= l.lockoutAttempts {
+		return l.lockoutDuration
+	}
+	if failures <= l.freeAttempts {
+		return 0
+	}
+
+	exp := float64(failures - l.freeAttempts - 1)
+	delay := time.Duration(float64(loginBaseDelay) * math.Pow(2, exp))
+	if delay > l.lockoutDuration {
+		return l.lockoutDuration
+	}
+
+	return delay
+}
+
+func parseInt(value string, defaultValue int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return defaultValue
+	}
+
+	return n
+}
+
+// parseCount is parseInt for settings where zero is meaningful. Only an
+// unset (empty), malformed or negative value falls back to the default.
+func parseCount(value string, defaultValue int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return defaultValue
+	}
+
+	return n
+}
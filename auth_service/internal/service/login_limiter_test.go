@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"auth.service/internal/repository"
+)
+
+func TestLoginDelay(t *testing.T) {
+	l := &LoginLimiterImpl{
+		freeAttempts:    3,
+		lockoutAttempts: 10,
+		lockoutDuration: 15 * time.Minute,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{9, 32 * time.Second},
+		{10, 15 * time.Minute},
+		{50, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := l.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottlesAfterFreeAttempts(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+	client := ClientInfo{IP: "192.0.2.1"}
+
+	s.createUser(t, "alice")
+
+	for i := range 4 {
+		_, err := s.auth.Login(ctx, "alice", "wrong", client)
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: Login = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+
+	// Throttled attempts are refused before the password is checked,
+	// so even the right one is.
+	_, err := s.auth.Login(ctx, "alice", testPassword, client)
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("Login = %v, want *ThrottledError", err)
+	}
+	if throttled.RetryAfter != time.Second {
+		t.Fatalf("RetryAfter = %s, want 1s", throttled.RetryAfter)
+	}
+
+	// Refused attempts are not counted.
+	attempt, err := s.attemptRepo.AttemptByKey(ctx, UsernameLimiterKey("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Failures != 4 {
+		t.Fatalf("failures = %d, want 4", attempt.Failures)
+	}
+}
+
+func TestLoginSuccessClearsUsernameAndReleasesIP(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+	client := ClientInfo{IP: "192.0.2.1"}
+
+	s.createUser(t, "alice")
+
+	for range 2 {
+		if _, err := s.auth.Login(ctx, "alice", "wrong", client); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Login = %v, want ErrInvalidCredentials", err)
+		}
+	}
+	if _, err := s.auth.Login(ctx, "alice", testPassword, client); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.attemptRepo.AttemptByKey(ctx, UsernameLimiterKey("alice"))
+	if !errors.Is(err, repository.ErrAttemptNotFound) {
+		t.Fatalf("username attempts = %v, want ErrAttemptNotFound", err)
+	}
+
+	// The IP keeps the failures from before the successful login.
+	attempt, err := s.attemptRepo.AttemptByKey(ctx, IPLimiterKey(client.IP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Failures != 2 {
+		t.Fatalf("IP failures = %d, want 2", attempt.Failures)
+	}
+}
+
+func TestParallelLoginsAreCountedBeforeTheCheck(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+
+	const attempts = 20
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.auth.Login(ctx, "alice", "wrong", ClientInfo{})
+		}()
+	}
+	wg.Wait()
+
+	checked := 0
+	for _, err := range errs {
+		var throttled *ThrottledError
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			checked++
+		case errors.As(err, &throttled):
+		default:
+			t.Fatalf("Login = %v", err)
+		}
+	}
+
+	// Three free attempts, plus the one that started the backoff.
+	if checked > 4 {
+		t.Fatalf("%d passwords were checked, want at most 4", checked)
+	}
+}
+
+func TestBlockedKeyChargesNoOtherKey(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	locked := UsernameLimiterKey("alice")
+	until := time.Now().Add(time.Hour)
+	_, err := s.db.Exec(
+		`INSERT INTO login_attempts (key, failures, last_failure_at, blocked_until)
+		VALUES (?, 10, ?, ?)`,
+		locked, time.Now(), until,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The blocked key comes last, after the other key has already been
+	// reserved within the transaction.
+	ip := IPLimiterKey("192.0.2.1")
+	err = s.limiter.Allow(ctx, ip, locked)
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("Allow = %v, want *ThrottledError", err)
+	}
+	if throttled.RetryAfter < 59*time.Minute {
+		t.Fatalf("RetryAfter = %s, want the lock of %s", throttled.RetryAfter, locked)
+	}
+
+	if _, err := s.attemptRepo.AttemptByKey(ctx, ip); !errors.Is(err, repository.ErrAttemptNotFound) {
+		t.Fatalf("IP attempts = %v, want ErrAttemptNotFound", err)
+	}
+	attempt, err := s.attemptRepo.AttemptByKey(ctx, locked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Failures != 10 {
+		t.Fatalf("failures of %s = %d, want 10", locked, attempt.Failures)
+	}
+}
+
+func TestChangePasswordValidationDoesNotCountAsFailure(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+
+	for range 5 {
+		_, err := s.auth.ChangePassword(ctx, userID, testPassword, "short", ClientInfo{})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("ChangePassword = %v, want *ValidationError", err)
+		}
+	}
+}
+
+func TestPruneDeletesStaleAttempts(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	now := time.Now()
+	stale := now.Add(-loginResetAfter - time.Hour)
+	rows := []struct {
+		key           string
+		lastFailureAt time.Time
+		blockedUntil  time.Time
+	}{
+		{"user:stale", stale, stale},
+		{"user:locked", stale, now.Add(time.Hour)},
+		{"user:recent", now.Add(-time.Minute), now.Add(-time.Minute)},
+	}
+	for _, row := range rows {
+		_, err := s.db.Exec(
+			`INSERT INTO login_attempts (key, failures, last_failure_at, blocked_until)
+			VALUES (?, 1, ?, ?)`,
+			row.key, row.lastFailureAt, row.blockedUntil,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := s.limiter.Prune(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Fatalf("pruned %d attempts, want 1", deleted)
+	}
+
+	if _, err := s.attemptRepo.AttemptByKey(ctx, "user:stale"); !errors.Is(err, repository.ErrAttemptNotFound) {
+		t.Fatalf("stale attempt = %v, want ErrAttemptNotFound", err)
+	}
+	for _, key := range []string{"user:locked", "user:recent"} {
+		if _, err := s.attemptRepo.AttemptByKey(ctx, key); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth.service/internal/config"
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+)
+
+const recoveryCodeCount = 10
+
+// MFAServiceImpl manages TOTP two-factor authentication. Enrollment is
+// two-step: the secret only protects logins once the user proves their
+// authenticator produces valid codes with ConfirmTOTP.
+type MFAServiceImpl struct {
+	mfaRepo  repository.MFARepository
+	userRepo repository.UserRepository
+	limiter  LoginLimiter
+	issuer   string
+}
+
+func NewMFAService(
+	mfaRepo repository.MFARepository,
+	userRepo repository.UserRepository,
+	limiter LoginLimiter,
+) *MFAServiceImpl {
+	return &MFAServiceImpl{
+		mfaRepo:  mfaRepo,
+		userRepo: userRepo,
+		limiter:  limiter,
+		issuer:   config.Env.TOTPIssuer,
+	}
+}
+
+func (s *MFAServiceImpl) EnrollTOTP(
+	ctx context.Context,
+	userID string,
+) (*TOTPEnrollment, error) {
+	op := "MFAService.EnrollTOTP"
+
+	user, err := s.userRepo.UserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	existing, err := s.mfaRepo.TOTPByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrTOTPNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if existing != nil && existing.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := pkg.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.mfaRepo.SaveTOTP(ctx, &repository.TOTPSecret{
+		UserID: userID,
+		Secret: secret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    pkg.TOTPURI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication and returns a fresh set
+// of recovery codes. They are only stored hashed, so this is the only
+// time the user sees them. Wrong codes are throttled like in VerifyMFA.
+func (s *MFAServiceImpl) ConfirmTOTP(
+	ctx context.Context,
+	userID, code string,
+	client ClientInfo,
+) ([]string, error) {
+	op := "MFAService.ConfirmTOTP"
+
+	secret, err := s.mfaRepo.TOTPByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if secret.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	limiterKeys := mfaLimiterKeys(userID, client)
+	if err := s.limiter.Allow(ctx, limiterKeys...); err != nil {
+		return nil, err
+	}
+
+	step, ok := pkg.ValidateTOTP(secret.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.mfaRepo.ConfirmTOTP(ctx, userID, step); err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := pkg.GenerateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		codes = append(codes, code)
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, codes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off. It needs a valid
+// code so a stolen access token alone cannot remove the second factor,
+// and wrong codes are throttled like in VerifyMFA so the code cannot be
+// guessed either.
+func (s *MFAServiceImpl) DisableTOTP(
+	ctx context.Context,
+	userID, code string,
+	client ClientInfo,
+) error {
+	op := "MFAService.DisableTOTP"
+
+	limiterKeys := mfaLimiterKeys(userID, client)
+	if err := s.limiter.Allow(ctx, limiterKeys...); err != nil {
+		return err
+	}
+
+	err := s.VerifyCode(ctx, userID, code)
+	if err != nil && !errors.Is(err, ErrMFANotEnabled) {
+		return err
+	}
+	// Without a second factor there was no code to guess.
+	if err := s.limiter.Succeed(ctx, limiterKeys...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.DeleteTOTP(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return ErrMFANotEnabled
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *MFAServiceImpl) IsEnabled(
+	ctx context.Context,
+	userID string,
+) (bool, error) {
+	op := "MFAService.IsEnabled"
+
+	secret, err := s.mfaRepo.TOTPByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret.ConfirmedAt != nil, nil
+}
+
+// mfaLimiterKeys returns the limiter keys second-factor codes are
+// throttled by.
+func mfaLimiterKeys(userID string, client ClientInfo) []string {
+	limiterKeys := []string{MFALimiterKey(userID)}
+	if client.IP != "" {
+		limiterKeys = append(limiterKeys, IPLimiterKey(client.IP))
+	}
+
+	return limiterKeys
+}
+
+// VerifyCode accepts each TOTP time step and each recovery code once.
+// It is not throttled itself; callers put it behind the LoginLimiter.
+func (s *MFAServiceImpl) VerifyCode(
+	ctx context.Context,
+	userID, code string,
+) error {
+	op := "MFAService.VerifyCode"
+
+	secret, err := s.mfaRepo.TOTPByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return ErrMFANotEnabled
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if secret.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	if len(code) == pkg.TOTPDigits {
+		step, ok := pkg.ValidateTOTP(secret.Secret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		if err := s.mfaRepo.UseTOTPStep(ctx, userID, step); err != nil {
+			if errors.Is(err, repository.ErrTOTPStepUsed) {
+				return ErrInvalidMFACode
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+
+	if err := s.mfaRepo.UseRecoveryCode(ctx, userID, code); err != nil {
+		if errors.Is(err, repository.ErrRecoveryCodeNotFound) {
+			return ErrInvalidMFACode
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
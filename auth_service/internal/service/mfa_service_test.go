@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"auth.service/internal/config"
+)
+
+// totpAt computes the code an authenticator app shows for secret in
+// the given time step.
+func totpAt(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	off := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func currentStep() int64 {
+	return time.Now().Unix() / 30
+}
+
+// enableTOTP enrolls and confirms TOTP for the user and returns the
+// secret, the step it was confirmed with and the recovery codes.
+func (s *testServices) enableTOTP(t *testing.T, userID string) (string, int64, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := s.mfa.EnrollTOTP(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := currentStep()
+	codes, err := s.mfa.ConfirmTOTP(ctx, userID, totpAt(t, enrollment.Secret, step), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return enrollment.Secret, step, codes
+}
+
+func TestLoginWithTOTP(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	secret, step, _ := s.enableTOTP(t, userID)
+
+	result, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tokens != nil || result.MFAToken == "" {
+		t.Fatalf("Login issued tokens without the second factor: %+v", result)
+	}
+
+	// The step used to confirm enrollment cannot be replayed.
+	if _, err := s.auth.VerifyMFA(ctx, result.MFAToken, totpAt(t, secret, step), ClientInfo{}); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("VerifyMFA with a used step = %v, want ErrInvalidMFACode", err)
+	}
+
+	tokens, err := s.auth.VerifyMFA(ctx, result.MFAToken, totpAt(t, secret, step+1), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each challenge is redeemed once.
+	if _, err := s.auth.VerifyMFA(ctx, result.MFAToken, totpAt(t, secret, step+1), ClientInfo{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("second VerifyMFA = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	_, _, codes := s.enableTOTP(t, userID)
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	if err := s.mfa.VerifyCode(ctx, userID, codes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.mfa.VerifyCode(ctx, userID, codes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("reused recovery code = %v, want ErrInvalidMFACode", err)
+	}
+}
+
+func TestConfirmTOTPIsThrottled(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	enrollment, err := s.mfa.EnrollTOTP(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 4 {
+		_, err := s.mfa.ConfirmTOTP(ctx, userID, "000000", ClientInfo{})
+		if !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: ConfirmTOTP = %v, want ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	_, err = s.mfa.ConfirmTOTP(ctx, userID, totpAt(t, enrollment.Secret, currentStep()), ClientInfo{})
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("ConfirmTOTP = %v, want *ThrottledError", err)
+	}
+}
+
+func TestDisableTOTPIsThrottled(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	_, _, codes := s.enableTOTP(t, userID)
+
+	for i := range 4 {
+		err := s.mfa.DisableTOTP(ctx, userID, "000000", ClientInfo{})
+		if !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: DisableTOTP = %v, want ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	err := s.mfa.DisableTOTP(ctx, userID, codes[0], ClientInfo{})
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("DisableTOTP = %v, want *ThrottledError", err)
+	}
+
+	enabled, err := s.mfa.IsEnabled(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Fatal("two-factor authentication was disabled while throttled")
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	_, _, codes := s.enableTOTP(t, userID)
+
+	if err := s.mfa.DisableTOTP(ctx, userID, codes[0], ClientInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.mfa.DisableTOTP(ctx, userID, codes[1], ClientInfo{}); !errors.Is(err, ErrMFANotEnabled) {
+		t.Fatalf("DisableTOTP twice = %v, want ErrMFANotEnabled", err)
+	}
+
+	// Disabling does not leave failures behind.
+	if _, err := s.attemptRepo.AttemptByKey(ctx, MFALimiterKey(userID)); err == nil {
+		t.Fatal("MFA limiter key was not cleared")
+	}
+
+	result, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tokens == nil {
+		t.Fatal("Login still asks for a second factor")
+	}
+}
+
+func TestMFAChallengeTTLFallsBack(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	secret, step, _ := s.enableTOTP(t, userID)
+
+	// A zero TTL would expire every challenge as it is issued.
+	config.Env.MFAChallengeTTL = "5 minutes"
+	auth := s.newAuthService()
+	if auth.mfaTTL != 5*time.Minute {
+		t.Fatalf("MFA_CHALLENGE_TTL=%q gives %v, want the 5m default", config.Env.MFAChallengeTTL, auth.mfaTTL)
+	}
+
+	result, err := auth.Login(ctx, "alice", testPassword, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.VerifyMFA(ctx, result.MFAToken, totpAt(t, secret, step+1), ClientInfo{}); err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"auth.service/internal/config"
+	"auth.service/pkg"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NewPasswordHasher builds the hasher new password hashes are made
+// with. Existing hashes of other algorithms or parameters keep working
+// and are replaced on the user's next successful login.
+func NewPasswordHasher() (pkg.PasswordHasher, error) {
+	argon2id := pkg.DefaultArgon2idHasher()
+	argon2id.Time = uint32(parseInt(config.Env.Argon2Time, int(argon2id.Time)))
+	argon2id.Memory = uint32(parseInt(config.Env.Argon2Memory, int(argon2id.Memory)))
+	argon2id.Threads = uint8(parseInt(config.Env.Argon2Threads, int(argon2id.Threads)))
+
+	return pkg.NewPasswordHasher(
+		config.Env.PasswordHashAlgorithm,
+		parseInt(config.Env.BcryptCost, bcrypt.DefaultCost),
+		argon2id,
+	)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"auth.service/pkg"
+)
+
+func passwordHash(t *testing.T, s *testServices, userID string) string {
+	t.Helper()
+
+	user, err := s.userRepo.UserByID(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user.PasswordHash
+}
+
+func TestNewPasswordHasherReadsConfig(t *testing.T) {
+	newTestServices(t, map[string]string{
+		"PASSWORD_HASH_ALGORITHM": "argon2id",
+		"ARGON2_TIME":             "1",
+		"ARGON2_MEMORY_KIB":       "64",
+		"ARGON2_THREADS":          "1",
+	})
+
+	hasher, err := NewPasswordHasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := hasher.Hash(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("hash %q does not use the configured parameters", hash)
+	}
+}
+
+func TestLoginRehashesOutdatedPasswordHash(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	if hash := passwordHash(t, s, userID); !strings.HasPrefix(hash, "$2") {
+		t.Fatalf("hash %q is not a bcrypt hash", hash)
+	}
+
+	// Switch the algorithm; the stored bcrypt hash keeps working.
+	argon2id := pkg.DefaultArgon2idHasher()
+	argon2id.Time = 1
+	argon2id.Memory = 64
+	argon2id.Threads = 1
+	s.auth.hasher = argon2id
+
+	if _, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := passwordHash(t, s, userID)
+	if argon2id.NeedsRehash(hash) {
+		t.Fatalf("hash %q was not replaced by an argon2id hash", hash)
+	}
+
+	if _, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{}); err != nil {
+		t.Fatalf("Login after rehash: %v", err)
+	}
+	if _, err := s.auth.Login(ctx, "alice", "wrong", ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login with wrong password = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestFailedLoginDoesNotRehash(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	before := passwordHash(t, s, userID)
+
+	s.auth.hasher = pkg.BcryptHasher{Cost: 5}
+
+	if _, err := s.auth.Login(ctx, "alice", "wrong", ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login = %v, want ErrInvalidCredentials", err)
+	}
+	if after := passwordHash(t, s, userID); after != before {
+		t.Fatal("hash changed after a failed login")
+	}
+}
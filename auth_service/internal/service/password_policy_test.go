@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCreateUserAppliesPasswordPolicy(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	_, err := s.users.CreateUser(context.Background(), "alice", "alice-password")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("CreateUser = %v, want *ValidationError", err)
+	}
+	for _, violation := range validationErr.Violations {
+		if violation.Field != "password" {
+			t.Fatalf("violation on field %q, want password", violation.Field)
+		}
+	}
+	if len(validationErr.Violations) != 2 {
+		t.Fatalf("got violations %+v, want classes and username", validationErr.Violations)
+	}
+}
+
+func TestPasswordMinClassesConfig(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"0", 0},
+		{"2", 2},
+		{"", 3},
+		{"-1", 3},
+		{"many", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			s := newTestServices(t, map[string]string{"PASSWORD_MIN_CLASSES": tt.value})
+			if got := s.users.policy.MinClasses; got != tt.want {
+				t.Fatalf("MinClasses = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPasswordReset(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	if err := s.auth.RequestPasswordReset(ctx, "ALICE", ClientInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	reset := s.notifier.last(t)
+	if reset.UserID != userID || reset.Username != "alice" {
+		t.Fatalf("reset sent to %q (%s), want alice (%s)", reset.Username, reset.UserID, userID)
+	}
+
+	var validationErr *ValidationError
+	if err := s.users.ResetPassword(ctx, reset.Token, "alice-123"); !errors.As(err, &validationErr) {
+		t.Fatalf("ResetPassword with a weak password = %v, want *ValidationError", err)
+	}
+
+	// A rejected password leaves the token usable.
+	if err := s.users.ResetPassword(ctx, reset.Token, "An0ther-Passw0rd"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.users.ResetPassword(ctx, reset.Token, "Y3t-An0ther-One"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("reusing a reset token = %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens after reset = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.auth.Login(ctx, "alice", "An0ther-Passw0rd", ClientInfo{}); err != nil {
+		t.Fatalf("Login with the new password: %v", err)
+	}
+}
+
+func TestRequestPasswordResetForUnknownUser(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	if err := s.auth.RequestPasswordReset(context.Background(), "nobody", ClientInfo{}); err != nil {
+		t.Fatalf("RequestPasswordReset = %v, want nil", err)
+	}
+	if len(s.notifier.resets) != 0 {
+		t.Fatal("a reset was sent for an unknown user")
+	}
+}
+
+func TestRequestPasswordResetIsThrottledPerUsername(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+
+	for _, username := range []string{"alice", "nobody"} {
+		for i := range 4 {
+			if err := s.auth.RequestPasswordReset(ctx, username, ClientInfo{}); err != nil {
+				t.Fatalf("%s: request %d: %v", username, i+1, err)
+			}
+		}
+
+		err := s.auth.RequestPasswordReset(ctx, username, ClientInfo{})
+		var throttled *ThrottledError
+		if !errors.As(err, &throttled) {
+			t.Fatalf("%s: RequestPasswordReset = %v, want *ThrottledError", username, err)
+		}
+	}
+	if len(s.notifier.resets) != 4 {
+		t.Fatalf("%d resets were sent, want 4", len(s.notifier.resets))
+	}
+
+	// Requesting resets does not lock the user out of logging in.
+	if _, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{}); err != nil {
+		t.Fatalf("Login after reset requests: %v", err)
+	}
+}
+
+func TestRequestPasswordResetIsThrottledPerIP(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+	client := ClientInfo{IP: "192.0.2.1"}
+
+	for _, username := range []string{"user1", "user2", "user3", "user4"} {
+		if err := s.auth.RequestPasswordReset(ctx, username, client); err != nil {
+			t.Fatalf("%s: %v", username, err)
+		}
+	}
+
+	err := s.auth.RequestPasswordReset(ctx, "user5", client)
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("RequestPasswordReset = %v, want *ThrottledError", err)
+	}
+
+	// Other clients are unaffected.
+	if err := s.auth.RequestPasswordReset(ctx, "user5", ClientInfo{IP: "192.0.2.2"}); err != nil {
+		t.Fatal(err)
+	}
+}
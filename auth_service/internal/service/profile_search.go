@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+)
+
+const (
+	defaultSearchLimit  = 20
+	maxSearchLimit      = 50
+	maxSearchQueryRunes = 64
+)
+
+// searchPageToken is the opaque next_page_token of SearchUsers. Like
+// pageToken it repeats the query so it cannot be replayed against a
+// different one.
+type searchPageToken struct {
+	Query              string `json:"q"`
+	Rank               int    `json:"r"`
+	NameLength         int    `json:"l"`
+	NormalizedUsername string `json:"u"`
+	UserID             string `json:"i"`
+}
+
+// SearchUsers finds users to chat with, e.g. for "/invite al<TAB>"
+// autocomplete. "al" matches the username "alice" by prefix, the
+// display name "Alan Smith", the username "sally" by substring and
+// "abel" by fuzzy subsequence, ranked in that order.
+func (s *ProfileServiceImpl) SearchUsers(
+	ctx context.Context,
+	params SearchUsersParams,
+) (*ProfilePage, error) {
+	op := "ProfileService.SearchUsers"
+
+	query := pkg.FoldText(pkg.CanonicalProfileText(params.Query))
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQueryRunes {
+		return nil, ErrInvalidQuery
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	search := repository.ProfileSearchQuery{
+		Query: query,
+		Limit: limit + 1,
+	}
+
+	if params.PageToken != "" {
+		token := new(searchPageToken)
+		if err := unmarshalPageToken(params.PageToken, token); err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		if token.Query != query || token.UserID == "" {
+			return nil, ErrInvalidPageToken
+		}
+
+		search.After = &repository.ProfileSearchCursor{
+			Rank:               token.Rank,
+			NameLength:         token.NameLength,
+			NormalizedUsername: token.NormalizedUsername,
+			UserID:             token.UserID,
+		}
+	}
+
+	matches, err := s.profileRepo.SearchProfiles(ctx, search)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &ProfilePage{}
+
+	// One extra row was requested to learn whether another page exists.
+	if len(matches) > limit {
+		matches = matches[:limit]
+		last := matches[len(matches)-1]
+
+		page.NextPageToken, err = encodePageToken(searchPageToken{
+			Query:              query,
+			Rank:               last.Rank,
+			NameLength:         last.NameLength,
+			NormalizedUsername: last.NormalizedUsername,
+			UserID:             last.UserID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	page.Profiles = make([]*Profile, 0, len(matches))
+	for _, match := range matches {
+		page.Profiles = append(page.Profiles, toProfile(&match.Profile))
+	}
+
+	return page, nil
+}
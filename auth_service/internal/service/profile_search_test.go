@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSearchFixture creates users that "al" matches in every rank, plus
+// some it must not return.
+func newSearchFixture(t *testing.T) *testServices {
+	t.Helper()
+
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	displayNames := map[string]string{
+		"zed": "ＡＬＡＮ Smith",
+		"yan": "Dr. Alvarez",
+		"bob": "Bob",
+	}
+	for _, username := range []string{
+		"alexander", "alice", "alvin", "zed", "yan", "sally", "abel",
+		"bob", "alfred", "alma",
+	} {
+		userID := s.createUser(t, username)
+
+		if name, ok := displayNames[username]; ok {
+			if _, err := s.profiles.UpdateProfile(ctx, userID, ProfileUpdate{
+				DisplayName: &name,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var err error
+		switch username {
+		case "alvin":
+			until := time.Now().Add(-time.Minute)
+			err = s.users.SetUserStatus(ctx, userID, StatusBanned, "", &until)
+		case "alfred":
+			err = s.users.SetUserStatus(ctx, userID, StatusBanned, "", nil)
+		case "alma":
+			err = s.users.DeleteUser(ctx, userID)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return s
+}
+
+func usernames(profiles []*Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Username)
+	}
+
+	return names
+}
+
+func TestSearchUsersRanking(t *testing.T) {
+	s := newSearchFixture(t)
+	ctx := context.Background()
+
+	// Username prefixes first, shortest first, then display name
+	// prefixes, substrings and fuzzy subsequences. Banned and deleted
+	// users are left out; alvin's ban has expired.
+	want := []string{"alice", "alvin", "alexander", "yan", "zed", "sally", "abel"}
+
+	for _, query := range []string{"al", " AL ", "ａｌ"} {
+		page, err := s.profiles.SearchUsers(ctx, SearchUsersParams{Query: query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := usernames(page.Profiles); !slices.Equal(got, want) {
+			t.Fatalf("SearchUsers(%q) = %v, want %v", query, got, want)
+		}
+		if page.NextPageToken != "" {
+			t.Fatalf("SearchUsers(%q) returned a next page token", query)
+		}
+	}
+
+	page, err := s.profiles.SearchUsers(ctx, SearchUsersParams{Query: "alan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// zed's display name was folded when saved; alexander only holds
+	// "alan" as a subsequence.
+	if got := usernames(page.Profiles); !slices.Equal(got, []string{"zed", "alexander"}) {
+		t.Fatalf("SearchUsers(alan) = %v, want [zed alexander]", got)
+	}
+}
+
+func TestSearchUsersEscapesWildcards(t *testing.T) {
+	s := newSearchFixture(t)
+
+	for _, query := range []string{"a_i", "a%e", `a\`} {
+		page, err := s.profiles.SearchUsers(context.Background(), SearchUsersParams{Query: query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Profiles) != 0 {
+			t.Fatalf("SearchUsers(%q) = %v, want no matches", query, usernames(page.Profiles))
+		}
+	}
+}
+
+func TestSearchUsersPaging(t *testing.T) {
+	s := newSearchFixture(t)
+	ctx := context.Background()
+
+	var got []string
+	params := SearchUsersParams{Query: "al", Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 4 {
+			t.Fatal("paging does not end")
+		}
+
+		page, err := s.profiles.SearchUsers(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Profiles) > 2 {
+			t.Fatalf("got %d profiles, want at most 2", len(page.Profiles))
+		}
+		got = append(got, usernames(page.Profiles)...)
+
+		if page.NextPageToken == "" {
+			break
+		}
+		params.PageToken = page.NextPageToken
+	}
+
+	want := []string{"alice", "alvin", "alexander", "yan", "zed", "sally", "abel"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("paged through %v, want %v", got, want)
+	}
+}
+
+func TestSearchUsersPageTokenIsBoundToQuery(t *testing.T) {
+	s := newSearchFixture(t)
+	ctx := context.Background()
+
+	page, err := s.profiles.SearchUsers(ctx, SearchUsersParams{Query: "al", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same query in another spelling may continue the search.
+	if _, err := s.profiles.SearchUsers(ctx, SearchUsersParams{
+		Query:     "AL",
+		Limit:     1,
+		PageToken: page.NextPageToken,
+	}); err != nil {
+		t.Fatalf("continuing with AL: %v", err)
+	}
+
+	for _, params := range []SearchUsersParams{
+		{Query: "a", PageToken: page.NextPageToken},
+		{Query: "al", PageToken: "garbage"},
+		{Query: "al", PageToken: page.NextPageToken[:len(page.NextPageToken)/2]},
+	} {
+		_, err := s.profiles.SearchUsers(ctx, params)
+		if !errors.Is(err, ErrInvalidPageToken) {
+			t.Fatalf("SearchUsers(%q, %q) = %v, want ErrInvalidPageToken", params.Query, params.PageToken, err)
+		}
+	}
+}
+
+func TestSearchUsersInvalidQuery(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	for _, query := range []string{"", "   ", strings.Repeat("a", maxSearchQueryRunes+1)} {
+		_, err := s.profiles.SearchUsers(context.Background(), SearchUsersParams{Query: query})
+		if !errors.Is(err, ErrInvalidQuery) {
+			t.Fatalf("SearchUsers(%q) = %v, want ErrInvalidQuery", query, err)
+		}
+	}
+
+	if _, err := s.profiles.SearchUsers(context.Background(), SearchUsersParams{
+		Query: strings.Repeat("ä", maxSearchQueryRunes),
+	}); err != nil {
+		t.Fatalf("a query of %d runes: %v", maxSearchQueryRunes, err)
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+)
+
+// MaxBatchUserIDs caps how many users one batch lookup may ask for.
+const MaxBatchUserIDs = 100
+
+type ProfileServiceImpl struct {
+	profileRepo repository.ProfileRepository
+}
+
+func NewProfileService(profileRepo repository.ProfileRepository) *ProfileServiceImpl {
+	return &ProfileServiceImpl{profileRepo: profileRepo}
+}
+
+func (s *ProfileServiceImpl) GetProfile(
+	ctx context.Context,
+	userID string,
+) (*Profile, error) {
+	op := "ProfileService.GetProfile"
+
+	profile, err := s.profileRepo.ProfileByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toProfile(profile), nil
+}
+
+func (s *ProfileServiceImpl) UpdateProfile(
+	ctx context.Context,
+	userID string,
+	update ProfileUpdate,
+) (*Profile, error) {
+	op := "ProfileService.UpdateProfile"
+
+	profile, err := s.profileRepo.ProfileByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fields := []struct {
+		name      string
+		value     *string
+		target    *string
+		maxLength int
+		multiline bool
+	}{
+		{"display_name", update.DisplayName, &profile.DisplayName, pkg.DisplayNameMaxLength, false},
+		{"bio", update.Bio, &profile.Bio, pkg.BioMaxLength, true},
+		{"avatar_attachment_id", update.AvatarAttachmentID, &profile.AvatarAttachmentID, pkg.AvatarAttachmentIDMaxLength, false},
+		{"status_text", update.StatusText, &profile.StatusText, pkg.StatusTextMaxLength, false},
+	}
+
+	var violations []FieldViolation
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+
+		value := pkg.CanonicalProfileText(*field.value)
+		descriptions := pkg.ValidateProfileText(field.name, value, field.maxLength, field.multiline)
+		violations = append(violations, fieldViolations(field.name, descriptions)...)
+
+		*field.target = value
+	}
+	if len(violations) > 0 {
+		return nil, &ValidationError{Violations: violations}
+	}
+
+	if err := s.profileRepo.SaveProfile(ctx, profile); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toProfile(profile), nil
+}
+
+func (s *ProfileServiceImpl) GetProfiles(
+	ctx context.Context,
+	userIDs []string,
+) ([]*Profile, error) {
+	op := "ProfileService.GetProfiles"
+
+	unique := uniqueValues(userIDs)
+	if len(unique) > MaxBatchUserIDs {
+		return nil, ErrTooManyUserIDs
+	}
+
+	profiles, err := s.profileRepo.ProfilesByUserIDs(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byID := make(map[string]*repository.Profile, len(profiles))
+	for _, profile := range profiles {
+		byID[profile.UserID] = profile
+	}
+
+	result := make([]*Profile, 0, len(profiles))
+	for _, id := range unique {
+		if profile, ok := byID[id]; ok {
+			result = append(result, toProfile(profile))
+		}
+	}
+
+	return result, nil
+}
+
+func toProfile(profile *repository.Profile) *Profile {
+	return &Profile{
+		UserID:             profile.UserID,
+		Username:           profile.Username,
+		DisplayName:        profile.DisplayName,
+		Bio:                profile.Bio,
+		AvatarAttachmentID: profile.AvatarAttachmentID,
+		StatusText:         profile.StatusText,
+		UpdatedAt:          profile.UpdatedAt,
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGetProfileDefaults(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "Alice")
+
+	profile, err := s.profiles.GetProfile(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.UserID != userID || profile.Username != "Alice" {
+		t.Fatalf("profile = %+v, want alice's", profile)
+	}
+	if profile.DisplayName != "" || profile.UpdatedAt != nil {
+		t.Fatalf("a new user has profile %+v, want an empty one", profile)
+	}
+
+	if _, err := s.profiles.GetProfile(ctx, "missing"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetProfile(missing) = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+
+	profile, err := s.profiles.UpdateProfile(ctx, userID, ProfileUpdate{
+		DisplayName: ptr("  Ａlice Liddell "),
+		Bio:         ptr("Down the\nrabbit hole"),
+		StatusText:  ptr("away"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.DisplayName != "Alice Liddell" {
+		t.Fatalf("DisplayName = %q, want it canonicalized", profile.DisplayName)
+	}
+	if profile.UpdatedAt == nil {
+		t.Fatal("UpdatedAt is nil after saving")
+	}
+
+	// Fields left nil keep their value, empty strings clear them.
+	if _, err := s.profiles.UpdateProfile(ctx, userID, ProfileUpdate{
+		StatusText:         ptr(""),
+		AvatarAttachmentID: ptr("att-1"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err = s.profiles.GetProfile(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Profile{
+		UserID:             userID,
+		Username:           "alice",
+		DisplayName:        "Alice Liddell",
+		Bio:                "Down the\nrabbit hole",
+		AvatarAttachmentID: "att-1",
+	}
+	profile.UpdatedAt = nil
+	if *profile != want {
+		t.Fatalf("profile = %+v, want %+v", *profile, want)
+	}
+
+	if _, err := s.profiles.UpdateProfile(ctx, "missing", ProfileUpdate{}); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("UpdateProfile(missing) = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestUpdateProfileValidation(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	if _, err := s.profiles.UpdateProfile(ctx, userID, ProfileUpdate{
+		DisplayName: ptr("Alice"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.profiles.UpdateProfile(ctx, userID, ProfileUpdate{
+		DisplayName:        ptr("Alice\nLiddell"),
+		Bio:                ptr(strings.Repeat("b", 501)),
+		AvatarAttachmentID: ptr(strings.Repeat("a", 128)),
+		StatusText:         ptr("away\x00"),
+	})
+
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("UpdateProfile = %v, want a ValidationError", err)
+	}
+	var fields []string
+	for _, v := range invalid.Violations {
+		fields = append(fields, v.Field)
+	}
+	if got := strings.Join(fields, ","); got != "display_name,bio,status_text" {
+		t.Fatalf("violations on %s, want display_name,bio,status_text", got)
+	}
+
+	// A rejected update saves nothing, not even its valid fields.
+	profile, err := s.profiles.GetProfile(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.DisplayName != "Alice" || profile.AvatarAttachmentID != "" {
+		t.Fatalf("profile = %+v after a rejected update", profile)
+	}
+}
+
+func TestGetProfiles(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	aliceID := s.createUser(t, "alice")
+	bobID := s.createUser(t, "bob")
+	carolID := s.createUser(t, "carol")
+	if err := s.users.DeleteUser(ctx, carolID); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := s.profiles.GetProfiles(ctx, []string{
+		bobID, "missing", aliceID, carolID, bobID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, profile := range profiles {
+		got = append(got, profile.Username)
+	}
+	if strings.Join(got, ",") != "bob,alice" {
+		t.Fatalf("GetProfiles = %v, want [bob alice]", got)
+	}
+
+	profiles, err = s.profiles.GetProfiles(ctx, nil)
+	if err != nil || len(profiles) != 0 {
+		t.Fatalf("GetProfiles(nil) = %v, %v, want no profiles", profiles, err)
+	}
+}
+
+func TestGetProfilesLimit(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	ids := make([]string, 0, MaxBatchUserIDs+1)
+	for i := range MaxBatchUserIDs {
+		ids = append(ids, fmt.Sprintf("id-%d", i))
+	}
+
+	// Duplicates do not count towards the limit.
+	if _, err := s.profiles.GetProfiles(ctx, append(ids, ids[0])); err != nil {
+		t.Fatalf("GetProfiles(%d unique IDs) = %v", MaxBatchUserIDs, err)
+	}
+
+	_, err := s.profiles.GetProfiles(ctx, append(ids, "one-more"))
+	if !errors.Is(err, ErrTooManyUserIDs) {
+		t.Fatalf("GetProfiles(%d IDs) = %v, want ErrTooManyUserIDs", MaxBatchUserIDs+1, err)
+	}
+}
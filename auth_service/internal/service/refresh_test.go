@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"auth.service/internal/config"
+	"auth.service/pkg"
+)
+
+// expireSession moves the expiry of the session holding refreshToken
+// into the past.
+func expireSession(t *testing.T, s *testServices, refreshToken string) {
+	t.Helper()
+
+	_, err := s.db.Exec(
+		`UPDATE sessions SET expires_at = ? WHERE refresh_token_hash = ?`,
+		time.Now().Add(-time.Minute),
+		pkg.HashToken(refreshToken),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshTokensRotates(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	first := s.login(t, "alice", "laptop")
+
+	second, err := s.auth.RefreshTokens(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	third, err := s.auth.RefreshTokens(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, third.AccessToken); err != nil {
+		t.Fatalf("ValidateToken of refreshed token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	first := s.login(t, "alice", "laptop")
+	other := s.login(t, "alice", "phone")
+
+	second, err := s.auth.RefreshTokens(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.auth.RefreshTokens(ctx, first.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reusing a rotated token = %v, want ErrTokenReused", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("newest token of a revoked family = %v, want ErrInvalidToken", err)
+	}
+
+	// Other families are unaffected.
+	if _, err := s.auth.RefreshTokens(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("other family: %v", err)
+	}
+}
+
+func TestConcurrentRefreshLooksLikeReuse(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	const attempts = 4
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.auth.RefreshTokens(ctx, tokens.RefreshToken)
+		}()
+	}
+	wg.Wait()
+
+	// Once reuse revoked the family, the remaining attempts find no
+	// session at all.
+	succeeded, reused := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrTokenReused):
+			reused++
+		case errors.Is(err, ErrInvalidToken):
+		default:
+			t.Fatalf("RefreshTokens = %v", err)
+		}
+	}
+	if succeeded > 1 {
+		t.Fatalf("%d concurrent refreshes succeeded, want at most 1", succeeded)
+	}
+	if reused == 0 {
+		t.Fatal("reuse was not detected")
+	}
+}
+
+func TestRefreshTokensExpired(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+	expireSession(t, s, tokens.RefreshToken)
+
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrExpiredToken) {
+		t.Fatalf("RefreshTokens = %v, want ErrExpiredToken", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens after expiry = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestDeleteExpiredSessionsKeepsLiveFamilies(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	live := s.login(t, "alice", "laptop")
+	rotated, err := s.auth.RefreshTokens(ctx, live.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := s.login(t, "alice", "phone")
+	expiredRotated, err := s.auth.RefreshTokens(ctx, expired.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expireSession(t, s, expired.RefreshToken)
+	expireSession(t, s, expiredRotated.RefreshToken)
+
+	deleted, err := s.auth.DeleteExpiredSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Fatalf("deleted %d sessions, want 2", deleted)
+	}
+
+	var remaining int
+	if err := s.db.Get(&remaining, `SELECT COUNT(*) FROM sessions`); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 2 {
+		t.Fatalf("%d sessions remain, want 2", remaining)
+	}
+
+	// The rotated session of the live family is still there, so reusing
+	// its token is still detected.
+	if _, err := s.auth.RefreshTokens(ctx, live.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reuse after cleanup = %v, want ErrTokenReused", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, rotated.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens of revoked family = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestSessionCleanupJobFallsBackToDefaultInterval(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	for _, value := range []string{"abc", "0", "-1h"} {
+		config.Env.SessionCleanupInterval = value
+		auth := s.newAuthService()
+		if auth.cleanupInterval != time.Hour {
+			t.Fatalf("SESSION_CLEANUP_INTERVAL=%q gives %v, want the 1h default", value, auth.cleanupInterval)
+		}
+
+		// A non-positive interval would make time.NewTicker panic.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		auth.RunSessionCleanupJob(ctx)
+	}
+}
+
+func TestParseDurationFallsBack(t *testing.T) {
+	for _, value := range []string{"", "abc", "0", "-1h"} {
+		if got := parseDuration(value, time.Hour); got != time.Hour {
+			t.Errorf("parseDuration(%q) = %v, want the default", value, got)
+		}
+	}
+	if got := parseDuration("2", time.Hour); got != 2*time.Hour {
+		t.Errorf("parseDuration(2) = %v, want 2h", got)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"auth.service/internal/config"
+	"auth.service/internal/repository"
+)
+
+// RevocationServiceImpl keeps revoked access tokens in SQLite and
+// mirrors them in memory, so checking a token never touches the
+// database. Load must be called once before the first check.
+type RevocationServiceImpl struct {
+	revocationRepo repository.RevocationRepository
+	accessTTL      time.Duration
+
+	mu      sync.RWMutex
+	tokens  map[string]time.Time
+	cutoffs map[string]repository.UserTokenRevocation
+}
+
+func NewRevocationService(
+	revocationRepo repository.RevocationRepository,
+) *RevocationServiceImpl {
+	return &RevocationServiceImpl{
+		revocationRepo: revocationRepo,
+		accessTTL:      parseDuration(config.Env.AccessTokenTTL, 15*time.Minute),
+		tokens:         make(map[string]time.Time),
+		cutoffs:        make(map[string]repository.UserTokenRevocation),
+	}
+}
+
+// Load fills the cache with revocations that can still affect an
+// unexpired access token.
+func (s *RevocationServiceImpl) Load(ctx context.Context) error {
+	op := "RevocationService.Load"
+
+	now := time.Now()
+
+	tokens, err := s.revocationRepo.RevokedTokens(ctx, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	cutoffs, err := s.revocationRepo.UserTokenRevocations(
+		ctx,
+		now.Add(-s.accessTTL),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range tokens {
+		s.tokens[token.JTI] = token.ExpiresAt
+	}
+	for _, cutoff := range cutoffs {
+		s.cutoffs[cutoff.UserID] = *cutoff
+	}
+
+	return nil
+}
+
+func (s *RevocationServiceImpl) RevokeToken(
+	ctx context.Context,
+	claims *TokenClaims,
+) error {
+	op := "RevocationService.RevokeToken"
+
+	now := time.Now()
+
+	err := s.revocationRepo.RevokeToken(ctx, &repository.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revocationRepo.DeleteExpiredTokens(ctx, now); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for jti, expiresAt := range s.tokens {
+		if !expiresAt.After(now) {
+			delete(s.tokens, jti)
+		}
+	}
+	s.tokens[claims.ID] = claims.ExpiresAt
+
+	return nil
+}
+
+// RevokeUserTokens revokes every access token issued to the user so
+// far. Token iat has second precision while the cutoff does not, so
+// tokens issued later within the same second are revoked too; KeepToken
+// exempts the one a caller issues right afterwards.
+func (s *RevocationServiceImpl) RevokeUserTokens(
+	ctx context.Context,
+	userID string,
+) error {
+	op := "RevocationService.RevokeUserTokens"
+
+	// The lock is held across the write so a concurrent KeepToken cannot
+	// exempt a token from a revocation issued after it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revocation := repository.UserTokenRevocation{
+		UserID:        userID,
+		RevokedBefore: time.Now(),
+	}
+
+	if err := s.revocationRepo.RevokeUserTokens(ctx, &revocation); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.cutoffs[userID] = revocation
+
+	return nil
+}
+
+// KeepToken exempts the token with the given ID from the user's latest
+// RevokeUserTokens. A later revocation covers it again.
+func (s *RevocationServiceImpl) KeepToken(
+	ctx context.Context,
+	userID, jti string,
+) error {
+	op := "RevocationService.KeepToken"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revocation, ok := s.cutoffs[userID]
+	if !ok {
+		return nil
+	}
+
+	if err := s.revocationRepo.KeepUserToken(ctx, userID, jti); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revocation.KeptJTI = jti
+	s.cutoffs[userID] = revocation
+
+	return nil
+}
+
+func (s *RevocationServiceImpl) IsRevoked(claims *TokenClaims) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tokens[claims.ID]; ok {
+		return true
+	}
+
+	if cutoff, ok := s.cutoffs[claims.UserID]; ok && claims.ID != cutoff.KeptJTI {
+		return !claims.IssuedAt.After(cutoff.RevokedBefore)
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth.service/internal/repository"
+	"auth.service/internal/repository/sqlite"
+)
+
+func TestIsRevokedCoversTheWholeCutoffSecond(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	cutoff := time.Date(2025, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+	s.revocations.cutoffs["user"] = repository.UserTokenRevocation{
+		UserID:        "user",
+		RevokedBefore: cutoff,
+		KeptJTI:       "kept",
+	}
+
+	tests := []struct {
+		name     string
+		claims   TokenClaims
+		expected bool
+	}{
+		{"earlier second", TokenClaims{ID: "a", UserID: "user", IssuedAt: cutoff.Add(-time.Second).Truncate(time.Second)}, true},
+		{"same second", TokenClaims{ID: "b", UserID: "user", IssuedAt: cutoff.Truncate(time.Second)}, true},
+		{"next second", TokenClaims{ID: "c", UserID: "user", IssuedAt: cutoff.Add(time.Second).Truncate(time.Second)}, false},
+		{"kept token", TokenClaims{ID: "kept", UserID: "user", IssuedAt: cutoff.Truncate(time.Second)}, false},
+		{"other user", TokenClaims{ID: "d", UserID: "other", IssuedAt: cutoff.Truncate(time.Second)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.revocations.IsRevoked(&tt.claims); got != tt.expected {
+				t.Fatalf("IsRevoked = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLogoutAllRevokesTokensIssuedInTheSameSecond(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	if err := s.auth.LogoutAll(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("ValidateToken after LogoutAll = %v, want ErrRevokedToken", err)
+	}
+}
+
+func TestChangePasswordKeepsOnlyTheNewToken(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	old := s.login(t, "alice", "laptop")
+
+	fresh, err := s.auth.ChangePassword(ctx, userID, testPassword, "An0ther-Passw0rd", ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.auth.ValidateToken(ctx, old.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("old token: ValidateToken = %v, want ErrRevokedToken", err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, fresh.AccessToken); err != nil {
+		t.Fatalf("new token: ValidateToken = %v", err)
+	}
+
+	// The exemption survives a restart.
+	reloaded := NewRevocationService(sqlite.NewRevocationRepository(s.db))
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		token   string
+		revoked bool
+	}{
+		{old.AccessToken, true},
+		{fresh.AccessToken, false},
+	} {
+		claims := parseClaims(t, s, tt.token)
+		if got := reloaded.IsRevoked(claims); got != tt.revoked {
+			t.Fatalf("after Load, IsRevoked = %v, want %v", got, tt.revoked)
+		}
+	}
+
+	// A later revocation covers the kept token again.
+	if err := s.auth.LogoutAll(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, fresh.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("after LogoutAll, ValidateToken = %v, want ErrRevokedToken", err)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	first := s.login(t, "alice", "laptop")
+	second := s.login(t, "alice", "phone")
+
+	if err := s.revocations.RevokeToken(ctx, parseClaims(t, s, first.AccessToken)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.auth.ValidateToken(ctx, first.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("revoked token: ValidateToken = %v, want ErrRevokedToken", err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, second.AccessToken); err != nil {
+		t.Fatalf("other token: ValidateToken = %v", err)
+	}
+}
+
+// parseClaims reads the claims of a token without checking revocation.
+func parseClaims(t *testing.T, s *testServices, accessToken string) *TokenClaims {
+	t.Helper()
+
+	revocations := s.auth.revocations
+	s.auth.revocations = NewRevocationService(sqlite.NewRevocationRepository(s.db))
+	defer func() { s.auth.revocations = revocations }()
+
+	claims, err := s.auth.ValidateToken(context.Background(), accessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return claims
+}
@@ -3,21 +3,120 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
+
+	"auth.service/internal/keys"
+	"auth.service/internal/repository"
 )
 
 var (
 	ErrInvalidToken       = errors.New("Invalid token")
 	ErrExpiredToken       = errors.New("Expired token")
+	ErrRevokedToken       = errors.New("Revoked token")
+	ErrTokenReused        = errors.New("Refresh token reused")
 	ErrTokenNotFound      = errors.New("Token not found")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnrolled     = errors.New("two-factor authentication not enrolled")
+	ErrMFANotEnabled      = errors.New("two-factor authentication not enabled")
+	ErrInvalidMFACode     = errors.New("invalid two-factor code")
+	ErrInvalidPageToken   = errors.New("invalid page token")
+	ErrInvalidOrderBy     = errors.New("invalid order_by")
+	ErrTooManyUserIDs     = errors.New("too many user IDs")
+	ErrInvalidQuery       = errors.New("invalid search query")
+)
+
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// ValidationError is returned when request fields break a policy, e.g.
+// a password that is too short or too common.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	descriptions := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		descriptions = append(descriptions, v.Field+": "+v.Description)
+	}
+
+	return "validation failed: " + strings.Join(descriptions, "; ")
+}
+
+const (
+	RoleUser  = repository.RoleUser
+	RoleAdmin = repository.RoleAdmin
 )
 
 type User struct {
-	ID       string
-	Username string
+	ID           string
+	Username     string
+	Role         string
+	Status       string
+	StatusReason string
+	StatusUntil  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ListUsersParams follows the List method conventions of the Google API
+// design guide: OrderBy is "username" or "created_at", optionally
+// followed by " desc".
+type ListUsersParams struct {
+	PageToken      string
+	PageSize       int
+	UsernamePrefix string
+	OrderBy        string
+}
+
+type UserPage struct {
+	Users         []*User
+	NextPageToken string
+}
+
+type Profile struct {
+	UserID             string
+	Username           string
+	DisplayName        string
+	Bio                string
+	AvatarAttachmentID string
+	StatusText         string
+	// UpdatedAt is nil if the user never saved a profile.
+	UpdatedAt *time.Time
+}
+
+// ProfileUpdate changes the fields that are not nil. An empty string
+// clears a field.
+type ProfileUpdate struct {
+	DisplayName        *string
+	Bio                *string
+	AvatarAttachmentID *string
+	StatusText         *string
+}
+
+type SearchUsersParams struct {
+	Query     string
+	Limit     int
+	PageToken string
+}
+
+type ProfilePage struct {
+	Profiles      []*Profile
+	NextPageToken string
+}
+
+// UserBatch is the result of a batch lookup: the users found, keyed by
+// ID, and the requested IDs or usernames that matched no user.
+type UserBatch struct {
+	Users   map[string]*User
+	Missing []string
 }
 
 type TokenPair struct {
@@ -26,25 +125,124 @@ type TokenPair struct {
 	RefreshToken string
 }
 
+// LoginResult holds a token pair, or for users with two-factor
+// authentication enabled, the challenge token VerifyMFA expects.
+type LoginResult struct {
+	UserID   string
+	Tokens   *TokenPair
+	MFAToken string
+}
+
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type Session struct {
+	ID        string
+	Device    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ClientInfo describes the client a request came from.
+type ClientInfo struct {
+	Device string
+	IP     string
+}
+
 type TokenClaims struct {
+	ID        string
 	UserID    string
 	Username  string
+	Role      string
+	IssuedAt  time.Time
 	ExpiresAt time.Time
 }
 
 type UserService interface {
-	CreateUser(ctx context.Context, username, password string) (string, error)
+	// CreateUser returns the new user with the username as stored, in
+	// its canonical form.
+	CreateUser(ctx context.Context, username, password string) (*User, error)
 	GetUserByID(ctx context.Context, userID string) (*User, error)
-	UpdateUser(ctx context.Context, userID, username, password string) error
+	GetUsersByIDs(ctx context.Context, userIDs []string) (*UserBatch, error)
+	// GetUsersByUsernames matches usernames by their normalized form.
+	GetUsersByUsernames(ctx context.Context, usernames []string) (*UserBatch, error)
+	UpdateUser(ctx context.Context, userID, username string) error
+	// SetPassword sets a password without the current one. It is meant
+	// for admins; owners use ChangePassword.
+	SetPassword(ctx context.Context, userID, password string) error
+	ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error
+	ListUsers(ctx context.Context, params ListUsersParams) (*UserPage, error)
+	SetUserStatus(ctx context.Context, userID, status, reason string, until *time.Time) error
+	RequestPasswordReset(ctx context.Context, username string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
 	DeleteUser(ctx context.Context, userID string) error
+	// RestoreUser restores a deleted account given its credentials and
+	// returns its ID.
+	RestoreUser(ctx context.Context, username, password string) (string, error)
+	RestoreUserByID(ctx context.Context, userID string) error
+	PurgeDeletedUsers(ctx context.Context) (int64, error)
 }
 
 type AuthService interface {
-	Login(ctx context.Context, username, password string) (*TokenPair, error)
+	Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error)
+	VerifyMFA(ctx context.Context, mfaToken, code string, client ClientInfo) (*TokenPair, error)
+	ChangePassword(ctx context.Context, userID, oldPassword, newPassword string, client ClientInfo) (*TokenPair, error)
+	RestoreUser(ctx context.Context, username, password string, client ClientInfo) (string, error)
+	RequestPasswordReset(ctx context.Context, username string, client ClientInfo) error
 	RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error)
 	ValidateToken(ctx context.Context, accessToken string) (*TokenClaims, error)
+	Logout(ctx context.Context, refreshToken, accessToken string) error
+	LogoutAll(ctx context.Context, userID string) error
+	ListSessions(ctx context.Context, userID string) ([]*Session, error)
+	// RevokeSession ends a session. A non-empty userID must own it.
+	RevokeSession(ctx context.Context, sessionID, userID string) error
+	// DeleteExpiredSessions removes the sessions of expired token
+	// families and returns how many were removed.
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+}
+
+type MFAService interface {
+	EnrollTOTP(ctx context.Context, userID string) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID, code string, client ClientInfo) ([]string, error)
+	DisableTOTP(ctx context.Context, userID, code string, client ClientInfo) error
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+	// VerifyCode accepts a current TOTP code or an unused recovery code.
+	VerifyCode(ctx context.Context, userID, code string) error
+}
+
+type ProfileService interface {
+	GetProfile(ctx context.Context, userID string) (*Profile, error)
+	UpdateProfile(ctx context.Context, userID string, update ProfileUpdate) (*Profile, error)
+	// GetProfiles returns the profiles of the given users in request
+	// order, skipping unknown users and duplicates.
+	GetProfiles(ctx context.Context, userIDs []string) ([]*Profile, error)
+	// SearchUsers matches usernames and display names by prefix and by
+	// fuzzy subsequence, best matches first.
+	SearchUsers(ctx context.Context, params SearchUsersParams) (*ProfilePage, error)
+}
+
+type RevocationService interface {
+	RevokeToken(ctx context.Context, claims *TokenClaims) error
+	RevokeUserTokens(ctx context.Context, userID string) error
+	// KeepToken exempts one token, issued right after RevokeUserTokens,
+	// from that revocation.
+	KeepToken(ctx context.Context, userID, jti string) error
+	IsRevoked(claims *TokenClaims) bool
+}
+
+// LoginLimiter throttles credential checks. Allow counts the attempt as
+// a failure up front; callers call Succeed with the same keys once the
+// credentials turned out to be right.
+type LoginLimiter interface {
+	Allow(ctx context.Context, keys ...string) error
+	Succeed(ctx context.Context, keys ...string) error
+	// Prune deletes state that no longer affects any key.
+	Prune(ctx context.Context) (int64, error)
 }
 
 type AcccessService interface {
 	Check(ctx context.Context, accessToken string) (bool, string, error)
+	PublicKeys(ctx context.Context) []keys.JWK
 }
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"auth.service/internal/config"
+	"auth.service/internal/keys"
+	"auth.service/internal/notifier"
+	"auth.service/internal/repository/sqlite"
+	"auth.service/internal/testutil"
+	"auth.service/pkg"
+	"github.com/jmoiron/sqlx"
+)
+
+const testPassword = "Tr1cky-Passw0rd"
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	resets []*notifier.PasswordReset
+}
+
+func (n *recordingNotifier) SendPasswordReset(
+	ctx context.Context,
+	reset *notifier.PasswordReset,
+) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.resets = append(n.resets, reset)
+	return nil
+}
+
+func (n *recordingNotifier) last(t *testing.T) *notifier.PasswordReset {
+	t.Helper()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.resets) == 0 {
+		t.Fatal("no password reset was sent")
+	}
+	return n.resets[len(n.resets)-1]
+}
+
+// testServices wires the services together the way app.Run does, on
+// top of a fresh database.
+type testServices struct {
+	db          *sqlx.DB
+	userRepo    *sqlite.SqliteUserRepository
+	sessionRepo *sqlite.SqliteSessionRepository
+	attemptRepo *sqlite.SqliteLoginAttemptRepository
+	mfaRepo     *sqlite.SqliteMFARepository
+	profileRepo *sqlite.SqliteProfileRepository
+	revocations *RevocationServiceImpl
+	limiter     *LoginLimiterImpl
+	mfa         *MFAServiceImpl
+	users       *UserServiceImpl
+	auth        *AuthServiceImpl
+	access      *AccessServiceImpl
+	profiles    *ProfileServiceImpl
+	notifier    *recordingNotifier
+	hasher      pkg.PasswordHasher
+	keySet      *keys.KeySet
+}
+
+func newTestServices(t *testing.T, vars map[string]string) *testServices {
+	t.Helper()
+
+	testutil.LoadEnv(t, vars)
+	db := testutil.OpenDB(t)
+
+	s := &testServices{
+		db:          db,
+		userRepo:    sqlite.NewUserRepository(db),
+		sessionRepo: sqlite.NewSessionRepository(db),
+		attemptRepo: sqlite.NewLoginAttemptRepository(db),
+		mfaRepo:     sqlite.NewMFARepository(db),
+		profileRepo: sqlite.NewProfileRepository(db),
+		notifier:    &recordingNotifier{},
+	}
+
+	s.revocations = NewRevocationService(sqlite.NewRevocationRepository(db))
+	if err := s.revocations.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	hasher, err := NewPasswordHasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.hasher = hasher
+
+	keySet, err := keys.Load(
+		config.Env.JWTSigningKeyPath,
+		config.Env.JWTPublicKeyPaths,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.keySet = keySet
+
+	s.limiter = NewLoginLimiter(s.attemptRepo)
+	s.mfa = NewMFAService(s.mfaRepo, s.userRepo, s.limiter)
+	s.users = NewUserService(
+		s.userRepo,
+		s.sessionRepo,
+		sqlite.NewPasswordResetRepository(db),
+		s.revocations,
+		hasher,
+		s.notifier,
+	)
+	s.auth = s.newAuthService()
+	s.access = NewAccessService(s.auth, s.userRepo, keySet)
+	s.profiles = NewProfileService(s.profileRepo)
+
+	return s
+}
+
+// newAuthService builds an AuthService from the current configuration,
+// for tests that change config.Env after newTestServices.
+func (s *testServices) newAuthService() *AuthServiceImpl {
+	return NewAuthService(
+		s.userRepo,
+		s.sessionRepo,
+		s.mfaRepo,
+		s.revocations,
+		s.limiter,
+		s.hasher,
+		s.mfa,
+		s.users,
+		s.keySet,
+		nil,
+		0,
+		0,
+	)
+}
+
+func (s *testServices) createUser(t *testing.T, username string) string {
+	t.Helper()
+
+	user, err := s.users.CreateUser(context.Background(), username, testPassword)
+	if err != nil {
+		t.Fatalf("CreateUser(%q): %v", username, err)
+	}
+
+	return user.ID
+}
+
+func (s *testServices) login(t *testing.T, username, device string) *TokenPair {
+	t.Helper()
+
+	result, err := s.auth.Login(
+		context.Background(),
+		username,
+		testPassword,
+		ClientInfo{Device: device},
+	)
+	if err != nil {
+		t.Fatalf("Login(%q): %v", username, err)
+	}
+	if result.Tokens == nil {
+		t.Fatalf("Login(%q) returned an MFA challenge", username)
+	}
+
+	return result.Tokens
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
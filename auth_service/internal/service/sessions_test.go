@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestListSessions(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	aliceID := s.createUser(t, "alice")
+	s.createUser(t, "bob")
+	s.login(t, "alice", "laptop")
+	s.login(t, "alice", "phone")
+	s.login(t, "bob", "tablet")
+
+	sessions, err := s.auth.ListSessions(ctx, aliceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	devices := map[string]bool{}
+	for _, session := range sessions {
+		devices[session.Device] = true
+	}
+	if !devices["laptop"] || !devices["phone"] {
+		t.Fatalf("got devices %v, want laptop and phone", devices)
+	}
+}
+
+func TestListSessionsShowsRotatedSessionOnce(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := s.auth.ListSessions(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+	other := s.login(t, "alice", "phone")
+
+	if err := s.auth.Logout(ctx, tokens.RefreshToken, tokens.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens after Logout = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("ValidateToken after Logout = %v, want ErrRevokedToken", err)
+	}
+
+	// The other session is untouched.
+	if _, err := s.auth.ValidateToken(ctx, other.AccessToken); err != nil {
+		t.Fatalf("ValidateToken of other session: %v", err)
+	}
+	sessions, err := s.auth.ListSessions(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Device != "phone" {
+		t.Fatalf("got sessions %+v, want only phone", sessions)
+	}
+
+	if err := s.auth.Logout(ctx, tokens.RefreshToken, ""); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("second Logout = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestLogoutRevokesAccessTokenOfSession(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	// The access token is revoked even if the client does not send it.
+	if err := s.auth.Logout(ctx, tokens.RefreshToken, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("ValidateToken after Logout = %v, want ErrRevokedToken", err)
+	}
+}
+
+func TestLogoutIgnoresAccessTokenOfAnotherUser(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	s.createUser(t, "bob")
+	alice := s.login(t, "alice", "laptop")
+	bob := s.login(t, "bob", "laptop")
+
+	if err := s.auth.Logout(ctx, alice.RefreshToken, bob.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.auth.ValidateToken(ctx, bob.AccessToken); err != nil {
+		t.Fatalf("ValidateToken of bob's token: %v", err)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	aliceID := s.createUser(t, "alice")
+	s.createUser(t, "bob")
+	first := s.login(t, "alice", "laptop")
+	second := s.login(t, "alice", "phone")
+	bob := s.login(t, "bob", "laptop")
+
+	if err := s.auth.LogoutAll(ctx, aliceID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tokens := range []*TokenPair{first, second} {
+		_, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("RefreshTokens after LogoutAll = %v, want ErrInvalidToken", err)
+		}
+	}
+
+	sessions, err := s.auth.ListSessions(ctx, aliceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("got %d sessions after LogoutAll, want 0", len(sessions))
+	}
+
+	if _, err := s.auth.RefreshTokens(ctx, bob.RefreshToken); err != nil {
+		t.Fatalf("RefreshTokens of bob: %v", err)
+	}
+
+	// Without sessions LogoutAll still succeeds.
+	if err := s.auth.LogoutAll(ctx, aliceID); err != nil {
+		t.Fatalf("second LogoutAll: %v", err)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	aliceID := s.createUser(t, "alice")
+	bobID := s.createUser(t, "bob")
+	tokens := s.login(t, "alice", "laptop")
+
+	sessions, err := s.auth.ListSessions(ctx, aliceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := sessions[0].ID
+
+	// Other users cannot tell the session exists.
+	if err := s.auth.RevokeSession(ctx, sessionID, bobID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("RevokeSession by bob = %v, want ErrSessionNotFound", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); err != nil {
+		t.Fatalf("session revoked by another user: %v", err)
+	}
+
+	if err := s.auth.RevokeSession(ctx, sessionID, aliceID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.auth.RevokeSession(ctx, sessionID, aliceID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("second RevokeSession = %v, want ErrSessionNotFound", err)
+	}
+
+	sessions, err = s.auth.ListSessions(ctx, aliceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("got %d sessions after RevokeSession, want 0", len(sessions))
+	}
+}
+
+func TestRevokeSessionRevokesAccessTokens(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	stolen := s.login(t, "alice", "laptop")
+	phone := s.login(t, "alice", "phone")
+
+	sessions, err := s.auth.ListSessions(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var laptopID string
+	for _, session := range sessions {
+		if session.Device == "laptop" {
+			laptopID = session.ID
+		}
+	}
+
+	rotated, err := s.auth.RefreshTokens(ctx, stolen.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.auth.RevokeSession(ctx, laptopID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every access token of the family stops working at once, not when
+	// it expires.
+	for _, accessToken := range []string{stolen.AccessToken, rotated.AccessToken} {
+		if _, err := s.auth.ValidateToken(ctx, accessToken); !errors.Is(err, ErrRevokedToken) {
+			t.Fatalf("ValidateToken after RevokeSession = %v, want ErrRevokedToken", err)
+		}
+	}
+	if _, err := s.auth.ValidateToken(ctx, phone.AccessToken); err != nil {
+		t.Fatalf("access token of another session: %v", err)
+	}
+}
+
+func TestRevokeSessionEndsRotatedFamily(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+	sessions, err := s.auth.ListSessions(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Revoking the original session ID ends the whole token family,
+	// including the refresh token it was rotated into.
+	if err := s.auth.RevokeSession(ctx, sessions[0].ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, rotated.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens after RevokeSession = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"auth.service/internal/keys"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeSigningKey writes a new Ed25519 private key and returns its path
+// and key ID.
+func writeSigningKey(t *testing.T) (string, string) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kid, err := keys.KeyID(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path, kid
+}
+
+func tokenHeader(t *testing.T, accessToken string) map[string]any {
+	t.Helper()
+
+	token, _, err := jwt.NewParser().ParseUnverified(accessToken, &CustomClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token.Header
+}
+
+func TestAccessTokensAreSignedWithTheSigningKey(t *testing.T) {
+	path, kid := writeSigningKey(t)
+	s := newTestServices(t, map[string]string{
+		"JWT_SECRET_KEY":       "",
+		"JWT_SIGNING_KEY_PATH": path,
+	})
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	header := tokenHeader(t, tokens.AccessToken)
+	if header["alg"] != "EdDSA" || header["kid"] != kid {
+		t.Fatalf("header = %v, want alg EdDSA and kid %s", header, kid)
+	}
+
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	jwks := s.access.PublicKeys(ctx)
+	if len(jwks) != 1 || jwks[0].KeyID != kid {
+		t.Fatalf("PublicKeys = %+v, want the signing key", jwks)
+	}
+}
+
+func TestTokensOutliveSigningKeyRotation(t *testing.T) {
+	oldPath, oldKID := writeSigningKey(t)
+	s := newTestServices(t, map[string]string{"JWT_SIGNING_KEY_PATH": oldPath})
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	oldSet, err := keys.Load(oldPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPublicKey, err := oldSet.VerificationKey(oldKID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(oldPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPublicPath := filepath.Join(t.TempDir(), "old.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(oldPublicPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	newPath, _ := writeSigningKey(t)
+	rotatedSet, err := keys.Load(newPath, []string{oldPublicPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := *s.auth
+	rotated.keySet = rotatedSet
+
+	if _, err := rotated.ValidateToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("token signed with the previous key: %v", err)
+	}
+
+	// Without the previous public key the token is rejected.
+	newSet, err := keys.Load(newPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated.keySet = newSet
+	if _, err := rotated.ValidateToken(ctx, tokens.AccessToken); err == nil {
+		t.Fatal("token with an unknown kid was accepted")
+	}
+}
+
+func TestHS256TokensNeedTheSecret(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+	if alg := tokenHeader(t, tokens.AccessToken)["alg"]; alg != "HS256" {
+		t.Fatalf("alg = %v, want HS256 without a signing key", alg)
+	}
+
+	path, _ := writeSigningKey(t)
+	set, err := keys.Load(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withSecret := *s.auth
+	withSecret.keySet = set
+	if _, err := withSecret.ValidateToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("HS256 token while the secret is configured: %v", err)
+	}
+
+	withoutSecret := withSecret
+	withoutSecret.jwtSecret = nil
+	if _, err := withoutSecret.ValidateToken(ctx, tokens.AccessToken); err == nil {
+		t.Fatal("HS256 token was accepted after the secret was removed")
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"auth.service/pkg"
+)
+
+// GetUsersByIDs looks up many users with one query. IDs of unknown or
+// deleted users are reported in Missing instead of failing the call.
+func (s *UserServiceImpl) GetUsersByIDs(
+	ctx context.Context,
+	userIDs []string,
+) (*UserBatch, error) {
+	op := "UserService.GetUsersByIDs"
+
+	unique := uniqueValues(userIDs)
+	if len(unique) > MaxBatchUserIDs {
+		return nil, ErrTooManyUserIDs
+	}
+
+	users, err := s.userRepo.UsersByIDs(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	batch := &UserBatch{Users: make(map[string]*User, len(users))}
+	for _, user := range users {
+		batch.Users[user.ID] = toUser(user)
+	}
+	for _, id := range unique {
+		if _, ok := batch.Users[id]; !ok {
+			batch.Missing = append(batch.Missing, id)
+		}
+	}
+
+	return batch, nil
+}
+
+// GetUsersByUsernames is GetUsersByIDs for usernames. Missing holds the
+// usernames as they were requested.
+func (s *UserServiceImpl) GetUsersByUsernames(
+	ctx context.Context,
+	usernames []string,
+) (*UserBatch, error) {
+	op := "UserService.GetUsersByUsernames"
+
+	unique := uniqueValues(usernames)
+	if len(unique) > MaxBatchUserIDs {
+		return nil, ErrTooManyUserIDs
+	}
+
+	users, err := s.userRepo.UsersByUsernames(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	batch := &UserBatch{Users: make(map[string]*User, len(users))}
+	found := make(map[string]bool, len(users))
+	for _, user := range users {
+		batch.Users[user.ID] = toUser(user)
+		found[user.NormalizedUsername] = true
+	}
+	for _, username := range unique {
+		if !found[pkg.NormalizeUsername(username)] {
+			batch.Missing = append(batch.Missing, username)
+		}
+	}
+
+	return batch, nil
+}
+
+// uniqueValues drops empty and repeated values, keeping the order of
+// first occurrence.
+func uniqueValues(values []string) []string {
+	unique := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
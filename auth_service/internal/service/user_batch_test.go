@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestGetUsersByIDs(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	aliceID := s.createUser(t, "alice")
+	bobID := s.createUser(t, "bob")
+	carolID := s.createUser(t, "carol")
+	if err := s.users.DeleteUser(ctx, carolID); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := s.users.GetUsersByIDs(ctx, []string{
+		aliceID, "missing", bobID, "", carolID, aliceID, "missing",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batch.Users) != 2 {
+		t.Fatalf("got %d users, want 2", len(batch.Users))
+	}
+	if batch.Users[aliceID].Username != "alice" || batch.Users[bobID].Username != "bob" {
+		t.Fatalf("users = %v, want alice and bob keyed by ID", batch.Users)
+	}
+	if want := []string{"missing", carolID}; !slices.Equal(batch.Missing, want) {
+		t.Fatalf("Missing = %q, want %q", batch.Missing, want)
+	}
+
+	batch, err = s.users.GetUsersByIDs(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Users) != 0 || len(batch.Missing) != 0 {
+		t.Fatalf("GetUsersByIDs(nil) = %+v, want an empty batch", batch)
+	}
+}
+
+func TestGetUsersByUsernames(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	aliceID := s.createUser(t, "Alice")
+	s.createUser(t, "bob")
+	carolID := s.createUser(t, "carol")
+	if err := s.users.DeleteUser(ctx, carolID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Usernames match by their normalized form, so several spellings
+	// may resolve to the same user.
+	batch, err := s.users.GetUsersByUsernames(ctx, []string{
+		"ALICE", "ａｌｉｃｅ", "Dave", "carol",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batch.Users) != 1 || batch.Users[aliceID] == nil {
+		t.Fatalf("users = %v, want only alice", batch.Users)
+	}
+	if got := batch.Users[aliceID].Username; got != "Alice" {
+		t.Fatalf("Username = %q, want it as registered", got)
+	}
+	if want := []string{"Dave", "carol"}; !slices.Equal(batch.Missing, want) {
+		t.Fatalf("Missing = %q, want %q", batch.Missing, want)
+	}
+}
+
+func TestBatchLookupLimit(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	values := make([]string, 0, MaxBatchUserIDs+1)
+	for i := range MaxBatchUserIDs {
+		values = append(values, fmt.Sprintf("user%d", i))
+	}
+
+	lookups := map[string]func([]string) (*UserBatch, error){
+		"GetUsersByIDs": func(v []string) (*UserBatch, error) {
+			return s.users.GetUsersByIDs(ctx, v)
+		},
+		"GetUsersByUsernames": func(v []string) (*UserBatch, error) {
+			return s.users.GetUsersByUsernames(ctx, v)
+		},
+	}
+
+	for name, lookup := range lookups {
+		batch, err := lookup(append(values, values[0]))
+		if err != nil {
+			t.Fatalf("%s(%d distinct values) = %v", name, MaxBatchUserIDs, err)
+		}
+		if len(batch.Missing) != MaxBatchUserIDs {
+			t.Fatalf("%s reported %d missing, want %d", name, len(batch.Missing), MaxBatchUserIDs)
+		}
+
+		if _, err := lookup(append(values, "one-more")); !errors.Is(err, ErrTooManyUserIDs) {
+			t.Fatalf("%s(%d values) = %v, want ErrTooManyUserIDs", name, MaxBatchUserIDs+1, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"auth.service/internal/repository"
+	"auth.service/pkg"
+)
+
+var ErrRestoreWindowExpired = errors.New("account can no longer be restored")
+
+// DeleteUser soft-deletes the user and ends all of its sessions. The
+// account can be restored until the grace period ends; after that the
+// purge job removes it and frees the username.
+func (s *UserServiceImpl) DeleteUser(ctx context.Context, userID string) error {
+	op := "UserService.DeleteUser"
+
+	if err := s.userRepo.DeleteUser(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revokeAll(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RestoreUser lets the owner of a deleted account undo the deletion by
+// proving they know its password. Unknown usernames and wrong passwords
+// both return ErrInvalidCredentials.
+func (s *UserServiceImpl) RestoreUser(
+	ctx context.Context,
+	username, password string,
+) (string, error) {
+	op := "UserService.RestoreUser"
+
+	user, err := s.userRepo.DeletedUserByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return "", ErrInvalidCredentials
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !pkg.CheckPasswordHash(password, user.PasswordHash) {
+		return "", ErrInvalidCredentials
+	}
+
+	if err := s.userRepo.RestoreUser(ctx, user.ID, s.restoreDeadline()); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return "", ErrRestoreWindowExpired
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user.ID, nil
+}
+
+// RestoreUserByID undoes a deletion on behalf of an admin.
+func (s *UserServiceImpl) RestoreUserByID(ctx context.Context, userID string) error {
+	op := "UserService.RestoreUserByID"
+
+	if err := s.userRepo.RestoreUser(ctx, userID, s.restoreDeadline()); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers hard-deletes the users whose grace period has
+// ended.
+func (s *UserServiceImpl) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	op := "UserService.PurgeDeletedUsers"
+
+	purged, err := s.userRepo.PurgeDeletedUsers(ctx, s.restoreDeadline())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purged, nil
+}
+
+// RunPurgeJob calls PurgeDeletedUsers once at start and then every
+// purge interval until ctx is done.
+func (s *UserServiceImpl) RunPurgeJob(ctx context.Context) {
+	ticker := time.NewTicker(s.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeDeletedUsers(ctx)
+		if err != nil {
+			log.Printf("failed to purge deleted users: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d deleted users", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// restoreDeadline is the deletion time before which accounts can no
+// longer be restored.
+func (s *UserServiceImpl) restoreDeadline() time.Time {
+	return time.Now().Add(-s.deleteGrace)
+}
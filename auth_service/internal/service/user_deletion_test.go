@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// deletedAgo moves the deletion time of the user into the past.
+func deletedAgo(t *testing.T, s *testServices, userID string, age time.Duration) {
+	t.Helper()
+
+	_, err := s.db.Exec(
+		`UPDATE users SET deleted_at = ? WHERE id = ?`,
+		time.Now().Add(-age),
+		userID,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	if err := s.users.DeleteUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.users.DeleteUser(ctx, userID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("deleting twice = %v, want ErrUserNotFound", err)
+	}
+
+	if _, err := s.users.GetUserByID(ctx, userID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetUserByID = %v, want ErrUserNotFound", err)
+	}
+	if _, err := s.auth.Login(ctx, "alice", testPassword, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("ValidateToken = %v, want ErrRevokedToken", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens = %v, want ErrInvalidToken", err)
+	}
+
+	// The username stays taken while the account can be restored.
+	if _, err := s.users.CreateUser(ctx, "Alice", testPassword); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("CreateUser = %v, want ErrUserAlreadyExists", err)
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	if err := s.users.DeleteUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, username := range []string{"alice", "bob"} {
+		_, err := s.auth.RestoreUser(ctx, username, "wrong", ClientInfo{})
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("RestoreUser(%s) with a wrong password = %v, want ErrInvalidCredentials", username, err)
+		}
+	}
+
+	restoredID, err := s.auth.RestoreUser(ctx, "ALICE", testPassword, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restoredID != userID {
+		t.Fatalf("restored %q, want %q", restoredID, userID)
+	}
+	s.login(t, "alice", "laptop")
+}
+
+func TestRestoreUserAfterGracePeriod(t *testing.T) {
+	s := newTestServices(t, map[string]string{"USER_DELETE_GRACE_PERIOD": "1h"})
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	if err := s.users.DeleteUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	deletedAgo(t, s, userID, 2*time.Hour)
+
+	for range 5 {
+		_, err := s.auth.RestoreUser(ctx, "alice", testPassword, ClientInfo{})
+		if !errors.Is(err, ErrRestoreWindowExpired) {
+			t.Fatalf("RestoreUser = %v, want ErrRestoreWindowExpired", err)
+		}
+	}
+	if err := s.users.RestoreUserByID(ctx, userID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("RestoreUserByID = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestGracePeriodInHours(t *testing.T) {
+	s := newTestServices(t, map[string]string{"USER_DELETE_GRACE_PERIOD": "720"})
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	if err := s.users.DeleteUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	deletedAgo(t, s, userID, 2*time.Hour)
+
+	if _, err := s.auth.RestoreUser(ctx, "alice", testPassword, ClientInfo{}); err != nil {
+		t.Fatalf("RestoreUser within 720 hours: %v", err)
+	}
+}
+
+func TestRestoreUserByID(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	if err := s.users.RestoreUserByID(ctx, userID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("restoring an active user = %v, want ErrUserNotFound", err)
+	}
+
+	if err := s.users.DeleteUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.users.RestoreUserByID(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	s.login(t, "alice", "laptop")
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	s := newTestServices(t, map[string]string{"USER_DELETE_GRACE_PERIOD": "1h"})
+	ctx := context.Background()
+
+	expiredID := s.createUser(t, "alice")
+	s.login(t, "alice", "laptop")
+	if _, err := s.profiles.UpdateProfile(ctx, expiredID, ProfileUpdate{DisplayName: ptr("Alice")}); err != nil {
+		t.Fatal(err)
+	}
+	recentID := s.createUser(t, "bob")
+	s.createUser(t, "carol")
+
+	for _, userID := range []string{expiredID, recentID} {
+		if err := s.users.DeleteUser(ctx, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deletedAgo(t, s, expiredID, 2*time.Hour)
+
+	purged, err := s.users.PurgeDeletedUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged %d users, want 1", purged)
+	}
+
+	for _, query := range []string{
+		`SELECT COUNT(*) FROM users WHERE id = ?`,
+		`SELECT COUNT(*) FROM sessions WHERE user_id = ?`,
+		`SELECT COUNT(*) FROM profiles WHERE user_id = ?`,
+		`SELECT COUNT(*) FROM user_token_revocations WHERE user_id = ?`,
+	} {
+		var count int
+		if err := s.db.Get(&count, query, expiredID); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%s = %d, want 0", query, count)
+		}
+	}
+
+	// The purged username is free again; the recent deletion still holds
+	// its name.
+	s.createUser(t, "alice")
+	if _, err := s.users.CreateUser(ctx, "bob", testPassword); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("CreateUser(bob) = %v, want ErrUserAlreadyExists", err)
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"auth.service/internal/repository"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 100
+)
+
+// pageToken is the opaque next_page_token. It repeats the listing
+// parameters so a token cannot be replayed against a different query.
+type pageToken struct {
+	OrderBy            string    `json:"o"`
+	Desc               bool      `json:"d,omitempty"`
+	UsernamePrefix     string    `json:"p,omitempty"`
+	NormalizedUsername string    `json:"u,omitempty"`
+	CreatedAt          time.Time `json:"c,omitzero"`
+	ID                 string    `json:"i"`
+}
+
+func (s *UserServiceImpl) ListUsers(
+	ctx context.Context,
+	params ListUsersParams,
+) (*UserPage, error) {
+	op := "UserService.ListUsers"
+
+	orderBy, desc, err := parseUserOrderBy(params.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := params.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultUserPageSize
+	}
+	if pageSize > maxUserPageSize {
+		pageSize = maxUserPageSize
+	}
+
+	query := repository.UserListQuery{
+		UsernamePrefix: params.UsernamePrefix,
+		OrderBy:        orderBy,
+		Desc:           desc,
+		Limit:          pageSize + 1,
+	}
+
+	if params.PageToken != "" {
+		token, err := decodePageToken(params.PageToken)
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		if token.OrderBy != orderBy ||
+			token.Desc != desc ||
+			token.UsernamePrefix != params.UsernamePrefix {
+			return nil, ErrInvalidPageToken
+		}
+
+		query.After = &repository.UserCursor{
+			NormalizedUsername: token.NormalizedUsername,
+			CreatedAt:          token.CreatedAt.Local(),
+			ID:                 token.ID,
+		}
+	}
+
+	users, err := s.userRepo.ListUsers(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &UserPage{}
+
+	// One extra row was requested to learn whether another page exists.
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		last := users[len(users)-1]
+
+		page.NextPageToken, err = encodePageToken(pageToken{
+			OrderBy:            orderBy,
+			Desc:               desc,
+			UsernamePrefix:     params.UsernamePrefix,
+			NormalizedUsername: last.NormalizedUsername,
+			CreatedAt:          last.CreatedAt,
+			ID:                 last.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	page.Users = make([]*User, 0, len(users))
+	for _, user := range users {
+		page.Users = append(page.Users, toUser(user))
+	}
+
+	return page, nil
+}
+
+func parseUserOrderBy(orderBy string) (string, bool, error) {
+	fields := strings.Fields(strings.ToLower(orderBy))
+
+	switch len(fields) {
+	case 0:
+		return repository.UserOrderUsername, false, nil
+	case 1, 2:
+	default:
+		return "", false, ErrInvalidOrderBy
+	}
+
+	field := fields[0]
+	if field != repository.UserOrderUsername && field != repository.UserOrderCreatedAt {
+		return "", false, ErrInvalidOrderBy
+	}
+
+	if len(fields) == 1 || fields[1] == "asc" {
+		return field, false, nil
+	}
+	if fields[1] == "desc" {
+		return field, true, nil
+	}
+
+	return "", false, ErrInvalidOrderBy
+}
+
+func encodePageToken(token any) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageToken(value string) (*pageToken, error) {
+	token := new(pageToken)
+	if err := unmarshalPageToken(value, token); err != nil {
+		return nil, err
+	}
+	if token.ID == "" {
+		return nil, ErrInvalidPageToken
+	}
+
+	return token, nil
+}
+
+func unmarshalPageToken(value string, token any) error {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, token)
+}
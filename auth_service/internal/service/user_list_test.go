@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// listAll pages through ListUsers and returns the usernames in order.
+func listAll(t *testing.T, s *testServices, params ListUsersParams) []string {
+	t.Helper()
+
+	var usernames []string
+	for range 100 {
+		page, err := s.users.ListUsers(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Users) > params.PageSize {
+			t.Fatalf("got %d users, want at most %d", len(page.Users), params.PageSize)
+		}
+		for _, user := range page.Users {
+			usernames = append(usernames, user.Username)
+		}
+
+		if page.NextPageToken == "" {
+			return usernames
+		}
+		params.PageToken = page.NextPageToken
+	}
+
+	t.Fatal("ListUsers did not stop returning page tokens")
+	return nil
+}
+
+func TestListUsersPages(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	for _, username := range []string{"dave", "Bob", "erin", "alice", "Carol"} {
+		s.createUser(t, username)
+	}
+
+	tests := []struct {
+		orderBy string
+		want    []string
+	}{
+		{"", []string{"alice", "Bob", "Carol", "dave", "erin"}},
+		{"username desc", []string{"erin", "dave", "Carol", "Bob", "alice"}},
+		{"created_at", []string{"dave", "Bob", "erin", "alice", "Carol"}},
+		{"CREATED_AT DESC", []string{"Carol", "alice", "erin", "Bob", "dave"}},
+	}
+
+	for _, tt := range tests {
+		for _, pageSize := range []int{1, 2, 5} {
+			got := listAll(t, s, ListUsersParams{OrderBy: tt.orderBy, PageSize: pageSize})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order %q, page size %d: got %v, want %v", tt.orderBy, pageSize, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestListUsersBreaksCreatedAtTies(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	for i := range 6 {
+		s.createUser(t, fmt.Sprintf("user%d", i))
+	}
+	// Users created in the same instant are still paged exactly once.
+	if _, err := s.db.Exec(`UPDATE users SET created_at = ?`, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, orderBy := range []string{"created_at", "created_at desc"} {
+		got := listAll(t, s, ListUsersParams{OrderBy: orderBy, PageSize: 4})
+		slices.Sort(got)
+		want := []string{"user0", "user1", "user2", "user3", "user4", "user5"}
+		if !slices.Equal(got, want) {
+			t.Errorf("order %q: got %v, want every user once", orderBy, got)
+		}
+	}
+}
+
+func TestListUsersPrefix(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	for _, username := range []string{"Alice", "alina", "al_x", "bob"} {
+		s.createUser(t, username)
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"AL", []string{"al_x", "Alice", "alina"}},
+		{"ali", []string{"Alice", "alina"}},
+		// LIKE wildcards in the prefix match only themselves.
+		{"al_", []string{"al_x"}},
+		{"%", nil},
+	}
+
+	for _, tt := range tests {
+		got := listAll(t, s, ListUsersParams{UsernamePrefix: tt.prefix, PageSize: 2})
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("prefix %q: got %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestListUsersPageSize(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	for i := range maxUserPageSize + 1 {
+		s.createUser(t, fmt.Sprintf("user%03d", i))
+	}
+
+	tests := []struct {
+		pageSize int
+		want     int
+	}{
+		{0, defaultUserPageSize},
+		{-1, defaultUserPageSize},
+		{1000, maxUserPageSize},
+	}
+
+	for _, tt := range tests {
+		page, err := s.users.ListUsers(context.Background(), ListUsersParams{PageSize: tt.pageSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Users) != tt.want {
+			t.Errorf("page size %d: got %d users, want %d", tt.pageSize, len(page.Users), tt.want)
+		}
+		if page.NextPageToken == "" {
+			t.Errorf("page size %d: no next page token", tt.pageSize)
+		}
+	}
+}
+
+func TestListUsersRejectsForeignPageTokens(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	for _, username := range []string{"alice", "alina", "bob"} {
+		s.createUser(t, username)
+	}
+
+	page, err := s.users.ListUsers(ctx, ListUsersParams{PageSize: 1, UsernamePrefix: "al"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := page.NextPageToken
+
+	tests := []struct {
+		name   string
+		params ListUsersParams
+	}{
+		{"other prefix", ListUsersParams{PageToken: token, UsernamePrefix: "b"}},
+		{"other order", ListUsersParams{PageToken: token, UsernamePrefix: "al", OrderBy: "created_at"}},
+		{"other direction", ListUsersParams{PageToken: token, UsernamePrefix: "al", OrderBy: "username desc"}},
+		{"not base64", ListUsersParams{PageToken: "!!!"}},
+		{"not JSON", ListUsersParams{PageToken: "bm90IGpzb24"}},
+		{"no cursor", ListUsersParams{PageToken: "eyJvIjoidXNlcm5hbWUifQ"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := s.users.ListUsers(ctx, tt.params); !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("%s: ListUsers = %v, want ErrInvalidPageToken", tt.name, err)
+		}
+	}
+}
+
+func TestListUsersRejectsInvalidOrderBy(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	for _, orderBy := range []string{"password_hash", "username sideways", "username desc extra"} {
+		_, err := s.users.ListUsers(context.Background(), ListUsersParams{OrderBy: orderBy})
+		if !errors.Is(err, ErrInvalidOrderBy) {
+			t.Errorf("order %q: ListUsers = %v, want ErrInvalidOrderBy", orderBy, err)
+		}
+	}
+}
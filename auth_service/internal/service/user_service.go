@@ -4,41 +4,85 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"auth.service/internal/config"
+	"auth.service/internal/notifier"
 	"auth.service/internal/repository"
 	"auth.service/pkg"
+	"github.com/google/uuid"
 )
 
 type UserServiceImpl struct {
-	userRepo repository.UserRepository
+	userRepo      repository.UserRepository
+	sessionRepo   repository.SessionRepository
+	resetRepo     repository.PasswordResetRepository
+	revocations   RevocationService
+	hasher        pkg.PasswordHasher
+	notifier      notifier.Notifier
+	policy        pkg.PasswordPolicy
+	resetTTL      time.Duration
+	deleteGrace   time.Duration
+	purgeInterval time.Duration
 }
 
-func NewUserService(userRepo repository.UserRepository) *UserServiceImpl {
-	return &UserServiceImpl{userRepo: userRepo}
+func NewUserService(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	resetRepo repository.PasswordResetRepository,
+	revocations RevocationService,
+	hasher pkg.PasswordHasher,
+	notifier notifier.Notifier,
+) *UserServiceImpl {
+	return &UserServiceImpl{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		resetRepo:   resetRepo,
+		revocations: revocations,
+		hasher:      hasher,
+		notifier:    notifier,
+		policy: pkg.PasswordPolicy{
+			MinLength:      parseInt(config.Env.PasswordMinLength, 8),
+			MinClasses:     parseCount(config.Env.PasswordMinClasses, 3),
+			ForbidUsername: parseBool(config.Env.PasswordForbidUsername, true),
+		},
+		resetTTL:      parseDuration(config.Env.PasswordResetTTL, 15*time.Minute),
+		deleteGrace:   parseDuration(config.Env.UserDeleteGracePeriod, 30*24*time.Hour),
+		purgeInterval: parseDuration(config.Env.UserPurgeInterval, time.Hour),
+	}
 }
 
 func (s *UserServiceImpl) CreateUser(
 	ctx context.Context,
 	username, password string,
-) (string, error) {
+) (*User, error) {
 	op := "UserService.CreateUser"
 
+	username = pkg.CanonicalUsername(username)
+
+	violations := usernameViolations(username)
+	violations = append(violations, s.passwordViolations(username, password)...)
+	if len(violations) > 0 {
+		return nil, &ValidationError{Violations: violations}
+	}
+
 	existingUser, err := s.userRepo.UserByUsername(ctx, username)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrUserNotFound):
 			break
 		default:
-			return "", fmt.Errorf("%s: %w", op, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 	}
 	if existingUser != nil {
-		return "", ErrUserAlreadyExists
+		return nil, ErrUserAlreadyExists
 	}
 
-	hashedPassword, err := pkg.HashPassword(password)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	user := &repository.User{
@@ -47,10 +91,13 @@ func (s *UserServiceImpl) CreateUser(
 	}
 
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		if errors.Is(err, repository.ErrUserAlreadyExists) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return user.ID, nil
+	return toUser(user), nil
 }
 
 func (s *UserServiceImpl) GetUserByID(ctx context.Context, userID string) (*User, error) {
@@ -58,35 +105,38 @@ func (s *UserServiceImpl) GetUserByID(ctx context.Context, userID string) (*User
 
 	user, err := s.userRepo.UserByID(ctx, userID)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	if user == nil {
-		return nil, ErrUserNotFound
-	}
 
-	return &User{
-		ID:       user.ID,
-		Username: user.Username,
-	}, nil
+	return toUser(user), nil
 }
 
+// UpdateUser changes the username. Passwords are changed with
+// ChangePassword, or by an admin with SetPassword.
 func (s *UserServiceImpl) UpdateUser(
 	ctx context.Context,
-	userID, username, password string,
+	userID, username string,
 ) error {
 	op := "UserService.UpdateUser"
 
 	user, err := s.userRepo.UserByID(ctx, userID)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	if user == nil {
-		return ErrUserNotFound
-	}
 
-	isChanged := false
+	username = pkg.CanonicalUsername(username)
 
 	if username != "" && user.Username != username {
+		if violations := usernameViolations(username); len(violations) > 0 {
+			return &ValidationError{Violations: violations}
+		}
+
 		existingUser, err := s.userRepo.UserByUsername(ctx, username)
 
 		if err != nil {
@@ -97,38 +147,257 @@ func (s *UserServiceImpl) UpdateUser(
 				return fmt.Errorf("%s: %w", op, err)
 			}
 		}
-		if existingUser != nil {
+		// Changing only the case of one's own username is allowed.
+		if existingUser != nil && existingUser.ID != user.ID {
 			return ErrUserAlreadyExists
 		}
 
 		user.Username = username
-		isChanged = true
+		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrUserAlreadyExists) {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
-	if password != "" {
-		hashedPassword, err := pkg.HashPassword(password)
-		if err != nil {
-			return fmt.Errorf("failed to hash password: %w", err)
+	return nil
+}
+
+// SetPassword replaces the password on behalf of an admin, without the
+// current one, and ends every session and access token of the user.
+func (s *UserServiceImpl) SetPassword(
+	ctx context.Context,
+	userID, password string,
+) error {
+	op := "UserService.SetPassword"
+
+	user, err := s.userRepo.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
 		}
-		user.PasswordHash = hashedPassword
-		isChanged = true
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if isChanged {
-		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+	if violations := s.passwordViolations(user.Username, password); len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revokeAll(ctx, user.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ChangePassword replaces the password after checking the current one
+// and ends every session and access token of the user.
+func (s *UserServiceImpl) ChangePassword(
+	ctx context.Context,
+	userID, oldPassword, newPassword string,
+) error {
+	op := "UserService.ChangePassword"
+
+	user, err := s.userRepo.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !pkg.CheckPasswordHash(oldPassword, user.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+
+	descriptions := s.policy.Validate(user.Username, newPassword)
+	if newPassword == oldPassword {
+		descriptions = append(descriptions,
+			"new password must differ from the current one")
+	}
+	if violations := fieldViolations("new_password", descriptions); len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revokeAll(ctx, user.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RequestPasswordReset sends a single-use reset token to the user. It
+// succeeds for unknown usernames too, so the RPC cannot be used to find
+// out which accounts exist.
+func (s *UserServiceImpl) RequestPasswordReset(
+	ctx context.Context,
+	username string,
+) error {
+	op := "UserService.RequestPasswordReset"
+
+	user, err := s.userRepo.UserByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
 		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	resetToken := &repository.PasswordResetToken{
+		Token:     uuid.New().String(),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}
+
+	if err := s.resetRepo.CreateResetToken(ctx, resetToken); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.notifier.SendPasswordReset(ctx, &notifier.PasswordReset{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Token:     resetToken.Token,
+		ExpiresAt: resetToken.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
-func (s *UserServiceImpl) DeleteUser(ctx context.Context, userID string) error {
-	op := "UserService.DeleteUser"
+// ResetPassword sets a new password using a token from
+// RequestPasswordReset and ends every session of the user.
+func (s *UserServiceImpl) ResetPassword(
+	ctx context.Context,
+	token, newPassword string,
+) error {
+	op := "UserService.ResetPassword"
+
+	resetToken, err := s.resetRepo.ResetTokenByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenNotFound) {
+			return ErrInvalidToken
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if time.Now().After(resetToken.ExpiresAt) {
+		err := s.resetRepo.DeleteResetToken(ctx, resetToken.ID)
+		if err != nil && !errors.Is(err, repository.ErrResetTokenNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return ErrExpiredToken
+	}
+
+	user, err := s.userRepo.UserByID(ctx, resetToken.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	descriptions := s.policy.Validate(user.Username, newPassword)
+	if violations := fieldViolations("new_password", descriptions); len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
 
-	if err := s.userRepo.DeleteUser(ctx, userID); err != nil {
+	// Deleting the token first makes it single-use even when two resets
+	// race.
+	if err := s.resetRepo.DeleteResetToken(ctx, resetToken.ID); err != nil {
+		if errors.Is(err, repository.ErrResetTokenNotFound) {
+			return ErrInvalidToken
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.revokeAll(ctx, user.ID); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
+
+func usernameViolations(username string) []FieldViolation {
+	return fieldViolations("username", pkg.ValidateUsername(username))
+}
+
+func (s *UserServiceImpl) passwordViolations(
+	username, password string,
+) []FieldViolation {
+	return fieldViolations("password", s.policy.Validate(username, password))
+}
+
+func fieldViolations(field string, descriptions []string) []FieldViolation {
+	violations := make([]FieldViolation, 0, len(descriptions))
+	for _, description := range descriptions {
+		violations = append(violations, FieldViolation{
+			Field:       field,
+			Description: description,
+		})
+	}
+
+	return violations
+}
+
+func toUser(user *repository.User) *User {
+	return &User{
+		ID:           user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		Status:       user.Status,
+		StatusReason: user.StatusReason,
+		StatusUntil:  user.StatusUntil,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
+
+// revokeAll ends every session of the user and invalidates the access
+// tokens already issued to them.
+func (s *UserServiceImpl) revokeAll(ctx context.Context, userID string) error {
+	err := s.sessionRepo.DeleteByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+		return err
+	}
+
+	return s.revocations.RevokeUserTokens(ctx, userID)
+}
+
+func parseBool(value string, defaultValue bool) bool {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return b
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUpdateUserChangesOnlyTheUsername(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	s.createUser(t, "bob")
+	tokens := s.login(t, "alice", "laptop")
+
+	// Changing only the case of one's own username is allowed.
+	if err := s.users.UpdateUser(ctx, userID, "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.users.UpdateUser(ctx, userID, "Bob"); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("UpdateUser to a taken username = %v, want ErrUserAlreadyExists", err)
+	}
+
+	// Renaming does not end sessions.
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("ValidateToken after rename: %v", err)
+	}
+	if _, err := s.auth.Login(ctx, "ALICE", testPassword, ClientInfo{}); err != nil {
+		t.Fatalf("Login after rename: %v", err)
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	tokens := s.login(t, "alice", "laptop")
+
+	var validationErr *ValidationError
+	if err := s.users.SetPassword(ctx, userID, "alice123"); !errors.As(err, &validationErr) {
+		t.Fatalf("SetPassword of a weak password = %v, want *ValidationError", err)
+	}
+	if err := s.users.SetPassword(ctx, "missing", "An0ther-Passw0rd"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("SetPassword of a missing user = %v, want ErrUserNotFound", err)
+	}
+
+	if err := s.users.SetPassword(ctx, userID, "An0ther-Passw0rd"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.auth.ValidateToken(ctx, tokens.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("ValidateToken after SetPassword = %v, want ErrRevokedToken", err)
+	}
+	if _, err := s.auth.RefreshTokens(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("RefreshTokens after SetPassword = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.auth.Login(ctx, "alice", "An0ther-Passw0rd", ClientInfo{}); err != nil {
+		t.Fatalf("Login with the new password: %v", err)
+	}
+}
+
+func TestChangePasswordOfMissingUser(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "alice")
+	if err := s.users.DeleteUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	const newPassword = "An0ther-Passw0rd"
+
+	for _, id := range []string{userID, "missing"} {
+		if err := s.users.ChangePassword(ctx, id, testPassword, newPassword); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("UserService.ChangePassword(%s) = %v, want ErrUserNotFound", id, err)
+		}
+		if _, err := s.auth.ChangePassword(ctx, id, testPassword, newPassword, ClientInfo{}); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("AuthService.ChangePassword(%s) = %v, want ErrUserNotFound", id, err)
+		}
+	}
+}
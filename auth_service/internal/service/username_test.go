@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUsernamesAreUniqueUpToCaseAndWidth(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	s.createUser(t, "Alice")
+
+	for _, username := range []string{"alice", "ALICE", "ａｌｉｃｅ", " Alice"} {
+		_, err := s.users.CreateUser(ctx, username, testPassword)
+		if !errors.Is(err, ErrUserAlreadyExists) {
+			t.Errorf("CreateUser(%q) = %v, want ErrUserAlreadyExists", username, err)
+		}
+	}
+}
+
+func TestLoginMatchesNormalizedUsername(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	userID := s.createUser(t, "Alice")
+
+	result, err := s.auth.Login(ctx, "ＡＬＩＣＥ", testPassword, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.UserID != userID {
+		t.Fatalf("logged in as %q, want %q", result.UserID, userID)
+	}
+
+	// The username is stored as it was entered.
+	user, err := s.userRepo.UserByID(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "Alice" {
+		t.Fatalf("Username = %q, want %q", user.Username, "Alice")
+	}
+}
+
+func TestCreateUserRejectsInvalidUsername(t *testing.T) {
+	s := newTestServices(t, nil)
+
+	_, err := s.users.CreateUser(context.Background(), "a b", testPassword)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("CreateUser = %v, want *ValidationError", err)
+	}
+	for _, violation := range validationErr.Violations {
+		if violation.Field != "username" {
+			t.Fatalf("violation on field %q, want username", violation.Field)
+		}
+	}
+}
+
+func TestCreateUserReturnsCanonicalUsername(t *testing.T) {
+	s := newTestServices(t, nil)
+	ctx := context.Background()
+
+	user, err := s.users.CreateUser(ctx, " Ａlice ", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "Alice" {
+		t.Fatalf("Username = %q, want %q", user.Username, "Alice")
+	}
+
+	stored, err := s.users.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Username != user.Username {
+		t.Fatalf("stored %q, returned %q", stored.Username, user.Username)
+	}
+}
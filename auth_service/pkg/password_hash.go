@@ -1,17 +1,191 @@
 package pkg
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUnknownHashAlgorithm = errors.New("unknown password hash algorithm")
+	ErrMalformedHash        = errors.New("malformed password hash")
+)
+
+// PasswordHasher produces self-describing hashes: the algorithm and its
+// parameters are encoded in the hash string, so hashes made with older
+// settings keep verifying after the configuration changes.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether the hash was made with another
+	// algorithm or with other parameters than the hasher's own.
+	NeedsRehash(hash string) bool
+}
+
+// HashPassword hashes with bcrypt at the default cost.
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword(
-		[]byte(password),
-		bcrypt.DefaultCost,
-	)
+	return BcryptHasher{Cost: bcrypt.DefaultCost}.Hash(password)
+}
+
+// CheckPasswordHash verifies a password against a hash made by any of
+// the supported algorithms.
+func CheckPasswordHash(password, hash string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		other := params.key(password, salt)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	case strings.HasPrefix(hash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// NewPasswordHasher returns the hasher for algorithm, "bcrypt" or
+// "argon2id".
+func NewPasswordHasher(
+	algorithm string,
+	bcryptCost int,
+	argon2id Argon2idHasher,
+) (PasswordHasher, error) {
+	switch algorithm {
+	case "bcrypt":
+		return BcryptHasher{Cost: bcryptCost}, nil
+	case "argon2id":
+		return argon2id, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHashAlgorithm, algorithm)
+	}
+}
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 
 	return string(bytes), err
 }
 
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+
+	return cost != h.Cost
+}
+
+// Argon2idHasher encodes hashes in the PHC string format:
+// $argon2id$v=19$m=<memory KiB>,t=<time>,p=<threads>$<salt>$<key>.
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+func DefaultArgon2idHasher() Argon2idHasher {
+	return Argon2idHasher{
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 2,
+		SaltLen: 16,
+		KeyLen:  32,
+	}
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := h.key(password, salt)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.Memory,
+		h.Time,
+		h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return true
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.Time != h.Time ||
+		params.Memory != h.Memory ||
+		params.Threads != h.Threads ||
+		uint32(len(salt)) != h.SaltLen ||
+		uint32(len(key)) != h.KeyLen
+}
+
+func (h Argon2idHasher) key(password string, salt []byte) []byte {
+	return argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+}
+
+func decodeArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	var params Argon2idHasher
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	_, err := fmt.Sscanf(
+		parts[3],
+		"m=%d,t=%d,p=%d",
+		&params.Memory,
+		&params.Time,
+		&params.Threads,
+	)
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	params.SaltLen = uint32(len(salt))
+	params.KeyLen = uint32(len(key))
+
+	return params, salt, key, nil
 }
@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idHasher is cheap enough to run in tests.
+func testArgon2idHasher() Argon2idHasher {
+	h := DefaultArgon2idHasher()
+	h.Time = 1
+	h.Memory = 64
+	h.Threads = 1
+	return h
+}
+
+func TestHashersRoundTrip(t *testing.T) {
+	hashers := map[string]PasswordHasher{
+		"bcrypt":   BcryptHasher{Cost: bcrypt.MinCost},
+		"argon2id": testArgon2idHasher(),
+	}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			hash, err := hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !CheckPasswordHash("correct horse", hash) {
+				t.Fatal("CheckPasswordHash rejected the right password")
+			}
+			if CheckPasswordHash("correct horsE", hash) {
+				t.Fatal("CheckPasswordHash accepted a wrong password")
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Fatal("NeedsRehash is true for the hasher's own hash")
+			}
+
+			other, err := hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if other == hash {
+				t.Fatal("two hashes of the same password are equal")
+			}
+		})
+	}
+}
+
+func TestArgon2idHashFormat(t *testing.T) {
+	hash, err := testArgon2idHasher().Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("hash %q does not encode its parameters", hash)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2idHash, err := testArgon2idHasher().Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testArgon2idHasher()
+	stronger.Time = 2
+
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+		hash   string
+		want   bool
+	}{
+		{"bcrypt cost changed", BcryptHasher{Cost: bcrypt.MinCost + 1}, bcryptHash, true},
+		{"bcrypt to argon2id", testArgon2idHasher(), bcryptHash, true},
+		{"argon2id to bcrypt", BcryptHasher{Cost: bcrypt.MinCost}, argon2idHash, true},
+		{"argon2id parameters changed", stronger, argon2idHash, true},
+		{"malformed", testArgon2idHasher(), "$argon2id$v=19$garbage", true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+			t.Errorf("%s: NeedsRehash = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPasswordHashRejectsMalformedHashes(t *testing.T) {
+	hashes := []string{
+		"",
+		"plaintext",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+	}
+
+	for _, hash := range hashes {
+		if CheckPasswordHash("", hash) {
+			t.Errorf("CheckPasswordHash accepted %q", hash)
+		}
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher("bcrypt", bcrypt.MinCost, testArgon2idHasher())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hasher.(BcryptHasher); !ok {
+		t.Fatalf("got %T, want BcryptHasher", hasher)
+	}
+
+	if _, err := NewPasswordHasher("md5", 0, testArgon2idHasher()); !errors.Is(err, ErrUnknownHashAlgorithm) {
+		t.Fatalf("NewPasswordHasher(md5) = %v, want ErrUnknownHashAlgorithm", err)
+	}
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+var commonPasswords = func() map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordsList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			passwords[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return passwords
+}()
+
+// PasswordPolicy describes what a new password must look like. Classes
+// are lowercase letters, uppercase letters, digits and everything else.
+type PasswordPolicy struct {
+	MinLength      int
+	MinClasses     int
+	ForbidUsername bool
+}
+
+// Validate returns one description per broken rule, or nil if the
+// password is acceptable.
+func (p PasswordPolicy) Validate(username, password string) []string {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf(
+			"password must be at least %d characters long",
+			p.MinLength,
+		))
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		violations = append(violations, fmt.Sprintf(
+			"password must contain at least %d of: lowercase letters, uppercase letters, digits, symbols",
+			p.MinClasses,
+		))
+	}
+
+	if p.ForbidUsername && username != "" &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "password must not contain the username")
+	}
+
+	if IsCommonPassword(password) {
+		violations = append(violations, "password is too common")
+	}
+
+	return violations
+}
+
+// IsCommonPassword reports whether the password is on the bundled list
+// of passwords seen in breaches.
+func IsCommonPassword(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+
+	return classes
+}
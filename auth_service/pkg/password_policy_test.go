@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MinClasses: 3, ForbidUsername: true}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     []string
+	}{
+		{"acceptable", "alice", "Tr1cky-Passw0rd", nil},
+		{"too short", "alice", "Ab1-", []string{"at least 8 characters"}},
+		{"length counts runes", "alice", "Äöü1-Äöü", nil},
+		{"too few classes", "alice", "lowercase-only", []string{"at least 3 of"}},
+		{"contains username", "alice", "xx-ALICE-99", []string{"must not contain the username"}},
+		{"common", "bob", "Iloveyou1", []string{"too common"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Validate(tt.username, tt.password)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Validate = %q, want %d violations", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Fatalf("violation %d = %q, want it to mention %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyOptionalRules(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 4}
+
+	if got := policy.Validate("alice", "alice"); got != nil {
+		t.Fatalf("Validate = %q, want no violations", got)
+	}
+	// The common list cannot be turned off.
+	if got := policy.Validate("bob", "qwerty"); len(got) != 1 {
+		t.Fatalf("Validate = %q, want the common password violation", got)
+	}
+}
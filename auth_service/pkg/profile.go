@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	DisplayNameMaxLength        = 64
+	BioMaxLength                = 500
+	StatusTextMaxLength         = 100
+	AvatarAttachmentIDMaxLength = 128
+)
+
+// CanonicalProfileText returns the NFKC form of a profile field with
+// surrounding whitespace removed.
+func CanonicalProfileText(value string) string {
+	return norm.NFKC.String(strings.TrimSpace(value))
+}
+
+// ValidateProfileText returns one description per broken rule for a
+// free-text profile field, or nil if the value is acceptable. Line
+// breaks are only allowed when multiline is set.
+func ValidateProfileText(field, value string, maxLength int, multiline bool) []string {
+	var violations []string
+
+	if utf8.RuneCountInString(value) > maxLength {
+		violations = append(violations, fmt.Sprintf(
+			"%s must be at most %d characters long",
+			field,
+			maxLength,
+		))
+	}
+
+	for _, r := range value {
+		if multiline && r == '\n' {
+			continue
+		}
+		if unicode.IsControl(r) {
+			violations = append(violations,
+				field+" must not contain control characters")
+			break
+		}
+	}
+
+	return violations
+}
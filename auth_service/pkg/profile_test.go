@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalProfileText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"  Alice  ", "Alice"},
+		{"Ａlice", "Alice"},
+		{"ﬁsh", "fish"},
+		{"\tline\n", "line"},
+	}
+
+	for _, tt := range tests {
+		if got := CanonicalProfileText(tt.value); got != tt.want {
+			t.Errorf("CanonicalProfileText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidateProfileText(t *testing.T) {
+	tests := []struct {
+		value     string
+		maxLength int
+		multiline bool
+		want      []string
+	}{
+		{"Alice", 5, false, nil},
+		{"Алиса", 5, false, nil},
+		{"Alice!", 5, false, []string{"at most 5 characters"}},
+		{"one\ntwo", 10, false, []string{"control characters"}},
+		{"one\ntwo", 10, true, nil},
+		{"one\ttwo", 10, true, []string{"control characters"}},
+		{"bell\a\a", 5, true, []string{"at most 5 characters", "control characters"}},
+	}
+
+	for _, tt := range tests {
+		got := ValidateProfileText("field", tt.value, tt.maxLength, tt.multiline)
+		if len(got) != len(tt.want) {
+			t.Errorf("ValidateProfileText(%q) = %q, want %d violations", tt.value, got, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.HasPrefix(got[i], "field ") || !strings.Contains(got[i], want) {
+				t.Errorf("ValidateProfileText(%q)[%d] = %q, want it to mention %q", tt.value, i, got[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken returns the SHA-256 of an opaque token, hex-encoded. Refresh
+// tokens are random UUIDs, so a fast unsalted hash is enough to keep a
+// leaked database from handing out live sessions.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
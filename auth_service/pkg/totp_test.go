@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors.
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).
+	EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateTOTPVectors(t *testing.T) {
+	// The RFC lists 8-digit codes; these are their last 6 digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTP(%s) at %d = false", tt.code, tt.unix)
+			continue
+		}
+		if want := tt.unix / 30; step != want {
+			t.Errorf("step = %d, want %d", step, want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	at := time.Unix(59, 0)
+
+	if step, ok := ValidateTOTP(rfc6238Secret, "287082", at.Add(TOTPPeriod)); !ok || step != 1 {
+		t.Fatalf("code of the previous step = (%d, %v), want (1, true)", step, ok)
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "287082", at.Add(2*TOTPPeriod)); ok {
+		t.Fatal("code two steps old was accepted")
+	}
+	// Lower case secrets, as some apps show them, are accepted.
+	if _, ok := ValidateTOTP(strings.ToLower(rfc6238Secret), "287082", at); !ok {
+		t.Fatal("lower case secret was rejected")
+	}
+}
+
+func TestValidateTOTPRejectsMalformedInput(t *testing.T) {
+	at := time.Unix(59, 0)
+
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, at); ok {
+			t.Errorf("ValidateTOTP accepted %q", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "287082", at); ok {
+		t.Error("ValidateTOTP accepted an invalid secret")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 20 {
+		t.Fatalf("secret has %d bytes, want 20", len(key))
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("Auth Service", "alice", "ABC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Auth Service:alice" {
+		t.Fatalf("unexpected URI %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != "ABC" || query.Get("issuer") != "Auth Service" ||
+		query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Fatalf("unexpected query %v", query)
+	}
+}
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	seen := make(map[string]bool)
+	counts := make(map[rune]int)
+	for range 2000 {
+		code, err := GenerateRecoveryCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(code) != 14 || code[4] != '-' || code[9] != '-' {
+			t.Fatalf("code %q is not formatted like xxxx-xxxx-xxxx", code)
+		}
+		if seen[code] {
+			t.Fatalf("code %q was generated twice", code)
+		}
+		seen[code] = true
+
+		for _, r := range NormalizeRecoveryCode(code) {
+			if !strings.ContainsRune(alphabet, r) {
+				t.Fatalf("code %q contains %q", code, r)
+			}
+			counts[r]++
+		}
+	}
+
+	// 24000 draws over 31 symbols average 774 each. The bounds are wide
+	// enough never to fail by chance; they catch symbols that are never
+	// or far too often drawn.
+	for _, r := range alphabet {
+		if counts[r] < 600 || counts[r] > 950 {
+			t.Fatalf("symbol %q drawn %d times, want about 774", r, counts[r])
+		}
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	if got := NormalizeRecoveryCode(" K7TQ-3wzm-X2PA "); got != "k7tq3wzmx2pa" {
+		t.Fatalf("NormalizeRecoveryCode = %q", got)
+	}
+}
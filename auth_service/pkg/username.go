@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 32
+)
+
+var usernameFolder = cases.Fold()
+
+// CanonicalUsername returns the NFKC form of a username as it should be
+// stored and shown.
+func CanonicalUsername(username string) string {
+	return norm.NFKC.String(strings.TrimSpace(username))
+}
+
+// NormalizeUsername returns the key usernames are compared by: NFKC
+// with Unicode case folding, so "Alice", "ALICE" and "ａｌｉｃｅ" are
+// the same user.
+func NormalizeUsername(username string) string {
+	return FoldText(CanonicalUsername(username))
+}
+
+// FoldText returns the NFKC case-folded form of text, for comparisons
+// that ignore case and Unicode width.
+func FoldText(text string) string {
+	folded := usernameFolder.String(norm.NFKC.String(text))
+	return norm.NFKC.String(folded)
+}
+
+// ValidateUsername returns one description per broken rule, or nil if
+// the username is acceptable. Usernames are letters and digits in any
+// script plus '_', '.' and '-', and must start with a letter or digit.
+func ValidateUsername(username string) []string {
+	var violations []string
+
+	username = CanonicalUsername(username)
+
+	length := utf8.RuneCountInString(username)
+	if length < UsernameMinLength || length > UsernameMaxLength {
+		violations = append(violations, fmt.Sprintf(
+			"username must be between %d and %d characters long",
+			UsernameMinLength,
+			UsernameMaxLength,
+		))
+	}
+
+	for i, r := range username {
+		if i == 0 && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			violations = append(violations, "username must start with a letter or digit")
+			break
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) &&
+			r != '_' && r != '.' && r != '-' {
+			violations = append(violations,
+				"username may only contain letters, digits, '_', '.' and '-'")
+			break
+		}
+	}
+
+	return violations
+}
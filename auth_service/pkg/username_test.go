@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     string
+	}{
+		{"alice", "alice"},
+		{"ALICE", "alice"},
+		{"  Alice ", "alice"},
+		{"ａｌｉｃｅ", "alice"},
+		{"Straße", "strasse"},
+		{"ΣΊΣΥΦΟΣ", "σίσυφοσ"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeUsername(tt.username); got != tt.want {
+			t.Errorf("NormalizeUsername(%q) = %q, want %q", tt.username, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalUsernameKeepsCase(t *testing.T) {
+	if got := CanonicalUsername(" Ａlice "); got != "Alice" {
+		t.Fatalf("CanonicalUsername = %q, want %q", got, "Alice")
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     []string
+	}{
+		{"alice", nil},
+		{"al", []string{"between 3 and 32"}},
+		{strings.Repeat("a", 33), []string{"between 3 and 32"}},
+		{"ｊｏｓé.m-1_x", nil},
+		{"Ивана", nil},
+		{"_alice", []string{"must start with"}},
+		{"al ice", []string{"may only contain"}},
+		{"al@ice", []string{"may only contain"}},
+		{"!", []string{"between 3 and 32", "must start with"}},
+	}
+
+	for _, tt := range tests {
+		got := ValidateUsername(tt.username)
+		if len(got) != len(tt.want) {
+			t.Errorf("ValidateUsername(%q) = %q, want %d violations", tt.username, got, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.Contains(got[i], want) {
+				t.Errorf("ValidateUsername(%q)[%d] = %q, want it to mention %q", tt.username, i, got[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Package verifier checks auth.service access tokens inside downstream
+// services without a network round trip per request.
+//
+// Public keys are fetched with AccessService.GetPublicKeys, cached and
+// refreshed in the background. Local verification cannot see token
+// revocations. The remote fallback only covers tokens that cannot be
+// verified locally, i.e. HS256 tokens and keys the verifier has not
+// seen; services that must reject revoked tokens enable
+// CheckRevocation, which also sends locally valid tokens to
+// AccessService.Check.
+package verifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	pb "auth.service/api/proto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "auth.service"
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("expired token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+	ErrRevokedToken = errors.New("revoked token")
+)
+
+type TokenClaims struct {
+	ID        string
+	UserID    string
+	Username  string
+	Role      string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// Remote is set when the token was checked by AccessService.Check,
+	// which only reports the user ID.
+	Remote bool
+}
+
+type Config struct {
+	// RefreshInterval is how often the public keys are refetched.
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits refetches triggered by unknown kids,
+	// whether the previous fetch succeeded or not.
+	MinRefreshInterval time.Duration
+	// RemoteFallback sends tokens that cannot be verified locally to
+	// AccessService.Check.
+	RemoteFallback bool
+	// CheckRevocation sends tokens that verify locally to
+	// AccessService.Check as well and rejects the ones it reports as
+	// invalid, e.g. revoked tokens. It costs a round trip per call.
+	CheckRevocation bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		RefreshInterval:    10 * time.Minute,
+		MinRefreshInterval: 30 * time.Second,
+	}
+}
+
+type claims struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type Verifier struct {
+	client pb.AccessServiceClient
+	cfg    Config
+
+	mu   sync.RWMutex
+	keys map[string]crypto.PublicKey
+	// lastAttempt is when the keys were last fetched, successfully or
+	// not.
+	lastAttempt time.Time
+}
+
+// New creates a verifier and loads the current public keys. A failed
+// initial fetch is not fatal when the remote fallback is enabled.
+func New(
+	ctx context.Context,
+	client pb.AccessServiceClient,
+	cfg Config,
+) (*Verifier, error) {
+	op := "verifier.New"
+
+	defaults := DefaultConfig()
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaults.RefreshInterval
+	}
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = defaults.MinRefreshInterval
+	}
+
+	v := &Verifier{
+		client: client,
+		cfg:    cfg,
+		keys:   make(map[string]crypto.PublicKey),
+	}
+
+	if err := v.Refresh(ctx); err != nil {
+		if !cfg.RemoteFallback {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		log.Printf("%s: %v, falling back to remote checks", op, err)
+	}
+
+	return v, nil
+}
+
+// Run refreshes the public keys every RefreshInterval until ctx is
+// done.
+func (v *Verifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.Refresh(ctx); err != nil {
+				log.Printf("verifier: failed to refresh public keys: %v", err)
+			}
+		}
+	}
+}
+
+func (v *Verifier) Refresh(ctx context.Context) error {
+	op := "verifier.Refresh"
+
+	v.mu.Lock()
+	v.lastAttempt = time.Now()
+	v.mu.Unlock()
+
+	return v.fetchKeys(ctx, op)
+}
+
+func (v *Verifier) fetchKeys(ctx context.Context, op string) error {
+	resp, err := v.client.GetPublicKeys(ctx, &pb.GetPublicKeysRequest{})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(resp.Keys))
+	for _, jwk := range resp.Keys {
+		key, err := publicKey(jwk)
+		if err != nil {
+			log.Printf("%s: skipping key %s: %v", op, jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.keys = keys
+
+	return nil
+}
+
+func (v *Verifier) Verify(ctx context.Context, accessToken string) (*TokenClaims, error) {
+	tokenClaims, err := v.verifyLocal(ctx, accessToken)
+	if err == nil {
+		if v.cfg.CheckRevocation {
+			return v.checkRevocation(ctx, accessToken, tokenClaims)
+		}
+		return tokenClaims, nil
+	}
+
+	if v.cfg.RemoteFallback && errors.Is(err, ErrUnknownKey) {
+		return v.verifyRemote(ctx, accessToken)
+	}
+
+	return nil, err
+}
+
+// checkRevocation asks the auth service about a token that verified
+// locally and keeps the local claims if it is still valid.
+func (v *Verifier) checkRevocation(
+	ctx context.Context,
+	accessToken string,
+	tokenClaims *TokenClaims,
+) (*TokenClaims, error) {
+	op := "verifier.checkRevocation"
+
+	resp, err := v.client.Check(ctx, &pb.CheckAccessRequest{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !resp.IsValid || resp.UserId != tokenClaims.UserID {
+		return nil, ErrRevokedToken
+	}
+
+	return tokenClaims, nil
+}
+
+func (v *Verifier) verifyLocal(ctx context.Context, accessToken string) (*TokenClaims, error) {
+	// HS256 tokens can only be checked by the auth service itself.
+	if isHMAC(accessToken) {
+		return nil, ErrUnknownKey
+	}
+
+	token, err := jwt.ParseWithClaims(
+		accessToken,
+		&claims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return v.key(ctx, token)
+		},
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodEdDSA.Alg(),
+			jwt.SigningMethodRS256.Alg(),
+		}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownKey):
+			return nil, ErrUnknownKey
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return nil, ErrExpiredToken
+		default:
+			return nil, ErrInvalidToken
+		}
+	}
+
+	c, ok := token.Claims.(*claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	tokenClaims := &TokenClaims{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		Username:  c.Username,
+		Role:      c.Role,
+		ExpiresAt: c.ExpiresAt.Time,
+	}
+	if c.IssuedAt != nil {
+		tokenClaims.IssuedAt = c.IssuedAt.Time
+	}
+
+	return tokenClaims, nil
+}
+
+func (v *Verifier) verifyRemote(ctx context.Context, accessToken string) (*TokenClaims, error) {
+	op := "verifier.verifyRemote"
+
+	resp, err := v.client.Check(ctx, &pb.CheckAccessRequest{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !resp.IsValid {
+		return nil, ErrInvalidToken
+	}
+
+	return &TokenClaims{
+		UserID: resp.UserId,
+		Remote: true,
+	}, nil
+}
+
+// key returns the public key for the token's kid, refetching the key
+// set once if the kid is new, e.g. right after a key rotation. The
+// attempt is reserved under the lock, so concurrent requests and a
+// failing auth service cause at most one fetch per MinRefreshInterval.
+func (v *Verifier) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	op := "verifier.key"
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	v.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	v.mu.Lock()
+	canRefresh := time.Since(v.lastAttempt) >= v.cfg.MinRefreshInterval
+	if canRefresh {
+		v.lastAttempt = time.Now()
+	}
+	v.mu.Unlock()
+
+	if !canRefresh {
+		return nil, ErrUnknownKey
+	}
+
+	if err := v.fetchKeys(ctx, op); err != nil {
+		log.Printf("%v", err)
+		return nil, ErrUnknownKey
+	}
+
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	v.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func isHMAC(accessToken string) bool {
+	token, _, err := jwt.NewParser().ParseUnverified(accessToken, &claims{})
+	if err != nil {
+		return false
+	}
+
+	_, ok := token.Method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+func publicKey(jwk *pb.JsonWebKey) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+}
@@ -0,0 +1,304 @@
+package verifier
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "auth.service/api/proto"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+)
+
+type fakeAccessClient struct {
+	mu         sync.Mutex
+	keys       []*pb.JsonWebKey
+	keysErr    error
+	keyFetches int
+	checks     int
+	valid      bool
+	userID     string
+}
+
+func (c *fakeAccessClient) Check(
+	ctx context.Context,
+	req *pb.CheckAccessRequest,
+	opts ...grpc.CallOption,
+) (*pb.CheckAccessResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks++
+	return &pb.CheckAccessResponse{IsValid: c.valid, UserId: c.userID}, nil
+}
+
+func (c *fakeAccessClient) GetPublicKeys(
+	ctx context.Context,
+	req *pb.GetPublicKeysRequest,
+	opts ...grpc.CallOption,
+) (*pb.GetPublicKeysResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keyFetches++
+	if c.keysErr != nil {
+		return nil, c.keysErr
+	}
+	return &pb.GetPublicKeysResponse{Keys: c.keys}, nil
+}
+
+func (c *fakeAccessClient) setKeys(keys []*pb.JsonWebKey, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys = keys
+	c.keysErr = err
+}
+
+func (c *fakeAccessClient) counts() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.keyFetches, c.checks
+}
+
+type signingKey struct {
+	kid     string
+	private ed25519.PrivateKey
+	jwk     *pb.JsonWebKey
+}
+
+func newSigningKey(t *testing.T, kid string) *signingKey {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &signingKey{
+		kid:     kid,
+		private: private,
+		jwk: &pb.JsonWebKey{
+			Kid: kid,
+			Kty: "OKP",
+			Alg: "EdDSA",
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(public),
+		},
+	}
+}
+
+func (k *signingKey) sign(t *testing.T, mutate func(*claims)) string {
+	t.Helper()
+
+	now := time.Now()
+	c := &claims{
+		UserID:   "user-1",
+		Username: "alice",
+		Role:     "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   "user-1",
+			ID:        "jti-1",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+	if mutate != nil {
+		mutate(c)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, c)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func newVerifier(t *testing.T, client *fakeAccessClient, cfg Config) *Verifier {
+	t.Helper()
+
+	v, err := New(context.Background(), client, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestVerifyLocal(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	client := &fakeAccessClient{keys: []*pb.JsonWebKey{key.jwk}}
+	v := newVerifier(t, client, Config{})
+
+	claims, err := v.Verify(context.Background(), key.sign(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != "user-1" || claims.Username != "alice" || claims.ID != "jti-1" || claims.Remote {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	if _, checks := client.counts(); checks != 0 {
+		t.Fatalf("Check was called %d times for a local verification", checks)
+	}
+}
+
+func TestVerifyRejectsBadTokens(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	other := newSigningKey(t, "k1")
+	client := &fakeAccessClient{keys: []*pb.JsonWebKey{key.jwk}}
+	v := newVerifier(t, client, Config{})
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", key.sign(t, func(c *claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}), ErrExpiredToken},
+		{"no expiry", key.sign(t, func(c *claims) { c.ExpiresAt = nil }), ErrInvalidToken},
+		{"wrong issuer", key.sign(t, func(c *claims) { c.Issuer = "elsewhere" }), ErrInvalidToken},
+		{"wrong key", other.sign(t, nil), ErrInvalidToken},
+		{"garbage", "not.a.token", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(context.Background(), tt.token); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyFetchesKeysForUnknownKID(t *testing.T) {
+	oldKey := newSigningKey(t, "old")
+	newKey := newSigningKey(t, "new")
+	client := &fakeAccessClient{keys: []*pb.JsonWebKey{oldKey.jwk}}
+	v := newVerifier(t, client, Config{MinRefreshInterval: time.Nanosecond})
+
+	client.setKeys([]*pb.JsonWebKey{oldKey.jwk, newKey.jwk}, nil)
+
+	if _, err := v.Verify(context.Background(), newKey.sign(t, nil)); err != nil {
+		t.Fatalf("token signed with a rotated-in key: %v", err)
+	}
+	if fetches, _ := client.counts(); fetches != 2 {
+		t.Fatalf("keys fetched %d times, want 2", fetches)
+	}
+}
+
+func TestUnknownKIDRefetchesAreRateLimited(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	unknown := newSigningKey(t, "unknown")
+	client := &fakeAccessClient{keys: []*pb.JsonWebKey{key.jwk}}
+	v := newVerifier(t, client, Config{MinRefreshInterval: time.Hour})
+
+	// The initial fetch counts as the last attempt.
+	for range 3 {
+		if _, err := v.Verify(context.Background(), unknown.sign(t, nil)); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("Verify = %v, want ErrUnknownKey", err)
+		}
+	}
+	if fetches, _ := client.counts(); fetches != 1 {
+		t.Fatalf("keys fetched %d times, want 1", fetches)
+	}
+}
+
+func TestFailedRefetchesAreRateLimited(t *testing.T) {
+	unknown := newSigningKey(t, "unknown")
+	client := &fakeAccessClient{keysErr: errors.New("unavailable")}
+	v := newVerifier(t, client, Config{RemoteFallback: true, MinRefreshInterval: time.Hour})
+
+	// Make the initial failed fetch old enough for one retry.
+	v.mu.Lock()
+	v.lastAttempt = time.Now().Add(-2 * time.Hour)
+	v.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v.verifyLocal(context.Background(), unknown.sign(t, nil))
+		}()
+	}
+	wg.Wait()
+
+	if fetches, _ := client.counts(); fetches != 2 {
+		t.Fatalf("keys fetched %d times, want 2", fetches)
+	}
+}
+
+func TestRemoteFallback(t *testing.T) {
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims{
+		UserID: "user-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &fakeAccessClient{valid: true, userID: "user-1"}
+
+	strict := newVerifier(t, client, Config{})
+	if _, err := strict.Verify(context.Background(), hmacToken); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Verify without fallback = %v, want ErrUnknownKey", err)
+	}
+
+	fallback := newVerifier(t, client, Config{RemoteFallback: true})
+	claims, err := fallback.Verify(context.Background(), hmacToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.Remote || claims.UserID != "user-1" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	client.valid = false
+	if _, err := fallback.Verify(context.Background(), hmacToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify of a token Check rejects = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestCheckRevocation(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	client := &fakeAccessClient{
+		keys:   []*pb.JsonWebKey{key.jwk},
+		valid:  true,
+		userID: "user-1",
+	}
+	v := newVerifier(t, client, Config{CheckRevocation: true})
+	token := key.sign(t, nil)
+
+	claims, err := v.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Remote || claims.Username != "alice" {
+		t.Fatalf("local claims were not kept: %+v", claims)
+	}
+
+	client.valid = false
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("Verify of a revoked token = %v, want ErrRevokedToken", err)
+	}
+
+	if _, checks := client.counts(); checks != 2 {
+		t.Fatalf("Check was called %d times, want 2", checks)
+	}
+}
@@ -24,6 +24,53 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Формат текста сообщения
+type MessageFormat int32
+
+const (
+	MessageFormat_MESSAGE_FORMAT_PLAIN    MessageFormat = 0 // Обычный текст
+	MessageFormat_MESSAGE_FORMAT_MARKDOWN MessageFormat = 1 // Подмножество markdown: жирный, курсив, код, ссылки
+)
+
+// Enum value maps for MessageFormat.
+var (
+	MessageFormat_name = map[int32]string{
+		0: "MESSAGE_FORMAT_PLAIN",
+		1: "MESSAGE_FORMAT_MARKDOWN",
+	}
+	MessageFormat_value = map[string]int32{
+		"MESSAGE_FORMAT_PLAIN":    0,
+		"MESSAGE_FORMAT_MARKDOWN": 1,
+	}
+)
+
+func (x MessageFormat) Enum() *MessageFormat {
+	p := new(MessageFormat)
+	*p = x
+	return p
+}
+
+func (x MessageFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MessageFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_chat_proto_enumTypes[0].Descriptor()
+}
+
+func (MessageFormat) Type() protoreflect.EnumType {
+	return &file_chat_proto_enumTypes[0]
+}
+
+func (x MessageFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MessageFormat.Descriptor instead.
+func (MessageFormat) EnumDescriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{0}
+}
+
 type CreateChatRequest struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
 	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                         // Необязательное имя чата
@@ -173,6 +220,8 @@ type ChatMessage struct {
 	Username      string                 `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`           // Имя отправителя (для удобства отображения)
 	Text          string                 `protobuf:"bytes,5,opt,name=text,proto3" json:"text,omitempty"`
 	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ForwardedFrom *ForwardedFrom         `protobuf:"bytes,7,opt,name=forwarded_from,json=forwardedFrom,proto3" json:"forwarded_from,omitempty"` // Заполнено, если сообщение переслано
+	Format        MessageFormat          `protobuf:"varint,8,opt,name=format,proto3,enum=chat.MessageFormat" json:"format,omitempty"`           // Как интерпретировать text
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -249,17 +298,113 @@ func (x *ChatMessage) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
-type SendMessageRequest struct {
+func (x *ChatMessage) GetForwardedFrom() *ForwardedFrom {
+	if x != nil {
+		return x.ForwardedFrom
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetFormat() MessageFormat {
+	if x != nil {
+		return x.Format
+	}
+	return MessageFormat_MESSAGE_FORMAT_PLAIN
+}
+
+// Информация об оригинале пересланного сообщения
+type ForwardedFrom struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ChatId        string                 `protobuf:"bytes,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
-	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"` // user_id отправителя будет взят из аутентификационного контекста (interceptor)
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // ID исходного сообщения
+	ChatId        string                 `protobuf:"bytes,2,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`          // ID исходного чата
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`          // ID автора оригинала
+	Username      string                 `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`                    // Имя автора оригинала
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                  // Время отправки оригинала
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *ForwardedFrom) Reset() {
+	*x = ForwardedFrom{}
+	mi := &file_chat_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardedFrom) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardedFrom) ProtoMessage() {}
+
+func (x *ForwardedFrom) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardedFrom.ProtoReflect.Descriptor instead.
+func (*ForwardedFrom) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ForwardedFrom) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ForwardedFrom) GetChatId() string {
+	if x != nil {
+		return x.ChatId
+	}
+	return ""
+}
+
+func (x *ForwardedFrom) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ForwardedFrom) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ForwardedFrom) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type SendMessageRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ChatId string                 `protobuf:"bytes,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	Text   string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// user_id отправителя будет взят из аутентификационного контекста (interceptor)
+	// Клиентский ID для повторов: повтор с тем же ID в пределах окна
+	// дедупликации вернет исходные message_id и timestamp
+	ClientMessageId string        `protobuf:"bytes,3,opt,name=client_message_id,json=clientMessageId,proto3" json:"client_message_id,omitempty"`
+	Format          MessageFormat `protobuf:"varint,4,opt,name=format,proto3,enum=chat.MessageFormat" json:"format,omitempty"` // Разметка проверяется и очищается на сервере
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
 func (x *SendMessageRequest) Reset() {
 	*x = SendMessageRequest{}
-	mi := &file_chat_proto_msgTypes[4]
+	mi := &file_chat_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -271,7 +416,7 @@ func (x *SendMessageRequest) String() string {
 func (*SendMessageRequest) ProtoMessage() {}
 
 func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[4]
+	mi := &file_chat_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -284,7 +429,7 @@ func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
 func (*SendMessageRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{4}
+	return file_chat_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *SendMessageRequest) GetChatId() string {
@@ -301,6 +446,20 @@ func (x *SendMessageRequest) GetText() string {
 	return ""
 }
 
+func (x *SendMessageRequest) GetClientMessageId() string {
+	if x != nil {
+		return x.ClientMessageId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetFormat() MessageFormat {
+	if x != nil {
+		return x.Format
+	}
+	return MessageFormat_MESSAGE_FORMAT_PLAIN
+}
+
 type SendMessageResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // ID отправленного сообщения
@@ -311,7 +470,7 @@ type SendMessageResponse struct {
 
 func (x *SendMessageResponse) Reset() {
 	*x = SendMessageResponse{}
-	mi := &file_chat_proto_msgTypes[5]
+	mi := &file_chat_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -323,7 +482,7 @@ func (x *SendMessageResponse) String() string {
 func (*SendMessageResponse) ProtoMessage() {}
 
 func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[5]
+	mi := &file_chat_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -336,7 +495,7 @@ func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendMessageResponse.ProtoReflect.Descriptor instead.
 func (*SendMessageResponse) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{5}
+	return file_chat_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *SendMessageResponse) GetMessageId() string {
@@ -353,6 +512,110 @@ func (x *SendMessageResponse) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
+type ForwardMessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`            // ID пересылаемого сообщения
+	TargetChatId  string                 `protobuf:"bytes,2,opt,name=target_chat_id,json=targetChatId,proto3" json:"target_chat_id,omitempty"` // В какой чат переслать
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardMessageRequest) Reset() {
+	*x = ForwardMessageRequest{}
+	mi := &file_chat_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardMessageRequest) ProtoMessage() {}
+
+func (x *ForwardMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardMessageRequest.ProtoReflect.Descriptor instead.
+func (*ForwardMessageRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ForwardMessageRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ForwardMessageRequest) GetTargetChatId() string {
+	if x != nil {
+		return x.TargetChatId
+	}
+	return ""
+}
+
+type ForwardMessageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // ID нового сообщения в целевом чате
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                  // Время пересылки на сервере
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardMessageResponse) Reset() {
+	*x = ForwardMessageResponse{}
+	mi := &file_chat_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardMessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardMessageResponse) ProtoMessage() {}
+
+func (x *ForwardMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardMessageResponse.ProtoReflect.Descriptor instead.
+func (*ForwardMessageResponse) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ForwardMessageResponse) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ForwardMessageResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
 var File_chat_proto protoreflect.FileDescriptor
 
 const file_chat_proto_rawDesc = "" +
@@ -365,7 +628,7 @@ const file_chat_proto_rawDesc = "" +
 	"\x12CreateChatResponse\x12\x17\n" +
 	"\achat_id\x18\x01 \x01(\tR\x06chatId\"-\n" +
 	"\x12ConnectChatRequest\x12\x17\n" +
-	"\achat_id\x18\x01 \x01(\tR\x06chatId\"\xc8\x01\n" +
+	"\achat_id\x18\x01 \x01(\tR\x06chatId\"\xb1\x02\n" +
 	"\vChatMessage\x12\x1d\n" +
 	"\n" +
 	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x17\n" +
@@ -373,19 +636,42 @@ const file_chat_proto_rawDesc = "" +
 	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1a\n" +
 	"\busername\x18\x04 \x01(\tR\busername\x12\x12\n" +
 	"\x04text\x18\x05 \x01(\tR\x04text\x128\n" +
-	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"A\n" +
+	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12:\n" +
+	"\x0eforwarded_from\x18\a \x01(\v2\x13.chat.ForwardedFromR\rforwardedFrom\x12+\n" +
+	"\x06format\x18\b \x01(\x0e2\x13.chat.MessageFormatR\x06format\"\xb6\x01\n" +
+	"\rForwardedFrom\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x17\n" +
+	"\achat_id\x18\x02 \x01(\tR\x06chatId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1a\n" +
+	"\busername\x18\x04 \x01(\tR\busername\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\x9a\x01\n" +
 	"\x12SendMessageRequest\x12\x17\n" +
 	"\achat_id\x18\x01 \x01(\tR\x06chatId\x12\x12\n" +
-	"\x04text\x18\x02 \x01(\tR\x04text\"n\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12*\n" +
+	"\x11client_message_id\x18\x03 \x01(\tR\x0fclientMessageId\x12+\n" +
+	"\x06format\x18\x04 \x01(\x0e2\x13.chat.MessageFormatR\x06format\"n\n" +
 	"\x13SendMessageResponse\x12\x1d\n" +
 	"\n" +
 	"message_id\x18\x01 \x01(\tR\tmessageId\x128\n" +
-	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp2\xd0\x01\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\\\n" +
+	"\x15ForwardMessageRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12$\n" +
+	"\x0etarget_chat_id\x18\x02 \x01(\tR\ftargetChatId\"q\n" +
+	"\x16ForwardMessageResponse\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp*F\n" +
+	"\rMessageFormat\x12\x18\n" +
+	"\x14MESSAGE_FORMAT_PLAIN\x10\x00\x12\x1b\n" +
+	"\x17MESSAGE_FORMAT_MARKDOWN\x10\x012\x9d\x02\n" +
 	"\vChatService\x12?\n" +
 	"\n" +
 	"CreateChat\x12\x17.chat.CreateChatRequest\x1a\x18.chat.CreateChatResponse\x12<\n" +
 	"\vConnectChat\x12\x18.chat.ConnectChatRequest\x1a\x11.chat.ChatMessage0\x01\x12B\n" +
-	"\vSendMessage\x12\x18.chat.SendMessageRequest\x1a\x19.chat.SendMessageResponseB4Z2github.com/TakuroBreath/chat-app/pkg/proto/chat_v1b\x06proto3"
+	"\vSendMessage\x12\x18.chat.SendMessageRequest\x1a\x19.chat.SendMessageResponse\x12K\n" +
+	"\x0eForwardMessage\x12\x1b.chat.ForwardMessageRequest\x1a\x1c.chat.ForwardMessageResponseB Z\x1echat.service/api/proto;chat_v1b\x06proto3"
 
 var (
 	file_chat_proto_rawDescOnce sync.Once
@@ -399,30 +685,42 @@ func file_chat_proto_rawDescGZIP() []byte {
 	return file_chat_proto_rawDescData
 }
 
-var file_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_chat_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_chat_proto_goTypes = []any{
-	(*CreateChatRequest)(nil),     // 0: chat.CreateChatRequest
-	(*CreateChatResponse)(nil),    // 1: chat.CreateChatResponse
-	(*ConnectChatRequest)(nil),    // 2: chat.ConnectChatRequest
-	(*ChatMessage)(nil),           // 3: chat.ChatMessage
-	(*SendMessageRequest)(nil),    // 4: chat.SendMessageRequest
-	(*SendMessageResponse)(nil),   // 5: chat.SendMessageResponse
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(MessageFormat)(0),             // 0: chat.MessageFormat
+	(*CreateChatRequest)(nil),      // 1: chat.CreateChatRequest
+	(*CreateChatResponse)(nil),     // 2: chat.CreateChatResponse
+	(*ConnectChatRequest)(nil),     // 3: chat.ConnectChatRequest
+	(*ChatMessage)(nil),            // 4: chat.ChatMessage
+	(*ForwardedFrom)(nil),          // 5: chat.ForwardedFrom
+	(*SendMessageRequest)(nil),     // 6: chat.SendMessageRequest
+	(*SendMessageResponse)(nil),    // 7: chat.SendMessageResponse
+	(*ForwardMessageRequest)(nil),  // 8: chat.ForwardMessageRequest
+	(*ForwardMessageResponse)(nil), // 9: chat.ForwardMessageResponse
+	(*timestamppb.Timestamp)(nil),  // 10: google.protobuf.Timestamp
 }
 var file_chat_proto_depIdxs = []int32{
-	6, // 0: chat.ChatMessage.timestamp:type_name -> google.protobuf.Timestamp
-	6, // 1: chat.SendMessageResponse.timestamp:type_name -> google.protobuf.Timestamp
-	0, // 2: chat.ChatService.CreateChat:input_type -> chat.CreateChatRequest
-	2, // 3: chat.ChatService.ConnectChat:input_type -> chat.ConnectChatRequest
-	4, // 4: chat.ChatService.SendMessage:input_type -> chat.SendMessageRequest
-	1, // 5: chat.ChatService.CreateChat:output_type -> chat.CreateChatResponse
-	3, // 6: chat.ChatService.ConnectChat:output_type -> chat.ChatMessage
-	5, // 7: chat.ChatService.SendMessage:output_type -> chat.SendMessageResponse
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	10, // 0: chat.ChatMessage.timestamp:type_name -> google.protobuf.Timestamp
+	5,  // 1: chat.ChatMessage.forwarded_from:type_name -> chat.ForwardedFrom
+	0,  // 2: chat.ChatMessage.format:type_name -> chat.MessageFormat
+	10, // 3: chat.ForwardedFrom.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 4: chat.SendMessageRequest.format:type_name -> chat.MessageFormat
+	10, // 5: chat.SendMessageResponse.timestamp:type_name -> google.protobuf.Timestamp
+	10, // 6: chat.ForwardMessageResponse.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 7: chat.ChatService.CreateChat:input_type -> chat.CreateChatRequest
+	3,  // 8: chat.ChatService.ConnectChat:input_type -> chat.ConnectChatRequest
+	6,  // 9: chat.ChatService.SendMessage:input_type -> chat.SendMessageRequest
+	8,  // 10: chat.ChatService.ForwardMessage:input_type -> chat.ForwardMessageRequest
+	2,  // 11: chat.ChatService.CreateChat:output_type -> chat.CreateChatResponse
+	4,  // 12: chat.ChatService.ConnectChat:output_type -> chat.ChatMessage
+	7,  // 13: chat.ChatService.SendMessage:output_type -> chat.SendMessageResponse
+	9,  // 14: chat.ChatService.ForwardMessage:output_type -> chat.ForwardMessageResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_chat_proto_init() }
@@ -435,13 +733,14 @@ func file_chat_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chat_proto_rawDesc), len(file_chat_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_chat_proto_goTypes,
 		DependencyIndexes: file_chat_proto_depIdxs,
+		EnumInfos:         file_chat_proto_enumTypes,
 		MessageInfos:      file_chat_proto_msgTypes,
 	}.Build()
 	File_chat_proto = out.File
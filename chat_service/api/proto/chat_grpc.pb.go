@@ -21,9 +21,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ChatService_CreateChat_FullMethodName  = "/chat.ChatService/CreateChat"
-	ChatService_ConnectChat_FullMethodName = "/chat.ChatService/ConnectChat"
-	ChatService_SendMessage_FullMethodName = "/chat.ChatService/SendMessage"
+	ChatService_CreateChat_FullMethodName     = "/chat.ChatService/CreateChat"
+	ChatService_ConnectChat_FullMethodName    = "/chat.ChatService/ConnectChat"
+	ChatService_SendMessage_FullMethodName    = "/chat.ChatService/SendMessage"
+	ChatService_ForwardMessage_FullMethodName = "/chat.ChatService/ForwardMessage"
 )
 
 // ChatServiceClient is the client API for ChatService service.
@@ -40,6 +41,9 @@ type ChatServiceClient interface {
 	ConnectChat(ctx context.Context, in *ConnectChatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatMessage], error)
 	// Отправка сообщения в чат
 	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*SendMessageResponse, error)
+	// Пересылка сообщения в другой чат
+	// Вызывающий должен иметь право читать исходный чат и писать в целевой
+	ForwardMessage(ctx context.Context, in *ForwardMessageRequest, opts ...grpc.CallOption) (*ForwardMessageResponse, error)
 }
 
 type chatServiceClient struct {
@@ -89,6 +93,16 @@ func (c *chatServiceClient) SendMessage(ctx context.Context, in *SendMessageRequ
 	return out, nil
 }
 
+func (c *chatServiceClient) ForwardMessage(ctx context.Context, in *ForwardMessageRequest, opts ...grpc.CallOption) (*ForwardMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForwardMessageResponse)
+	err := c.cc.Invoke(ctx, ChatService_ForwardMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChatServiceServer is the server API for ChatService service.
 // All implementations must embed UnimplementedChatServiceServer
 // for forward compatibility.
@@ -103,6 +117,9 @@ type ChatServiceServer interface {
 	ConnectChat(*ConnectChatRequest, grpc.ServerStreamingServer[ChatMessage]) error
 	// Отправка сообщения в чат
 	SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error)
+	// Пересылка сообщения в другой чат
+	// Вызывающий должен иметь право читать исходный чат и писать в целевой
+	ForwardMessage(context.Context, *ForwardMessageRequest) (*ForwardMessageResponse, error)
 	mustEmbedUnimplementedChatServiceServer()
 }
 
@@ -122,6 +139,9 @@ func (UnimplementedChatServiceServer) ConnectChat(*ConnectChatRequest, grpc.Serv
 func (UnimplementedChatServiceServer) SendMessage(context.Context, *SendMessageRequest) (*SendMessageResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
 }
+func (UnimplementedChatServiceServer) ForwardMessage(context.Context, *ForwardMessageRequest) (*ForwardMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForwardMessage not implemented")
+}
 func (UnimplementedChatServiceServer) mustEmbedUnimplementedChatServiceServer() {}
 func (UnimplementedChatServiceServer) testEmbeddedByValue()                     {}
 
@@ -190,6 +210,24 @@ func _ChatService_SendMessage_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChatService_ForwardMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForwardMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).ForwardMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatService_ForwardMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).ForwardMessage(ctx, req.(*ForwardMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChatService_ServiceDesc is the grpc.ServiceDesc for ChatService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -205,6 +243,10 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendMessage",
 			Handler:    _ChatService_SendMessage_Handler,
 		},
+		{
+			MethodName: "ForwardMessage",
+			Handler:    _ChatService_ForwardMessage_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,27 @@
+package broker
+
+import (
+	"context"
+	"errors"
+
+	pb "chat.service/api/proto"
+)
+
+var ErrBrokerClosed = errors.New("broker is closed")
+
+// Handler receives every message published to the broker, including
+// the ones published by this replica.
+type Handler func(msg *pb.ChatMessage)
+
+// Broker carries live chat messages between chat_service replicas. Each
+// replica publishes the messages it accepts and relays everything it
+// receives into its local hub with b.Subscribe(ctx, hub.Publish).
+type Broker interface {
+	Publish(ctx context.Context, msg *pb.ChatMessage) error
+	Subscribe(ctx context.Context, handler Handler) (Subscription, error)
+	Close() error
+}
+
+type Subscription interface {
+	Unsubscribe() error
+}
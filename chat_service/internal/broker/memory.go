@@ -0,0 +1,80 @@
+package broker
+
+import (
+	"context"
+	"sync"
+
+	pb "chat.service/api/proto"
+)
+
+// MemoryBroker delivers messages within a single process. It is the
+// default when chat_service runs as one replica.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[*memorySubscription]Handler
+	closed   bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		handlers: make(map[*memorySubscription]Handler),
+	}
+}
+
+// Publish calls the handlers after releasing the lock, so a handler may
+// unsubscribe or publish again without deadlocking.
+func (b *MemoryBroker) Publish(ctx context.Context, msg *pb.ChatMessage) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBrokerClosed
+	}
+
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(msg)
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+
+	sub := &memorySubscription{broker: b}
+	b.handlers[sub] = handler
+
+	return sub, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	clear(b.handlers)
+
+	return nil
+}
+
+type memorySubscription struct {
+	broker *MemoryBroker
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	delete(s.broker.handlers, s)
+	return nil
+}
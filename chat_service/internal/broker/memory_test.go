@@ -0,0 +1,103 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "chat.service/api/proto"
+)
+
+func TestMemoryBrokerDeliversToAllSubscribers(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx := context.Background()
+
+	got := make(chan string, 2)
+	for range 2 {
+		_, err := b.Subscribe(ctx, func(msg *pb.ChatMessage) {
+			got <- msg.MessageId
+		})
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+	}
+
+	if err := b.Publish(ctx, &pb.ChatMessage{MessageId: "m1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	for range 2 {
+		if id := <-got; id != "m1" {
+			t.Fatalf("got message %q, want m1", id)
+		}
+	}
+}
+
+func TestMemoryBrokerUnsubscribe(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx := context.Background()
+
+	calls := 0
+	sub, err := b.Subscribe(ctx, func(*pb.ChatMessage) { calls++ })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	b.Publish(ctx, &pb.ChatMessage{})
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	b.Publish(ctx, &pb.ChatMessage{})
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestMemoryBrokerHandlerMayUnsubscribeAndPublish(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx := context.Background()
+
+	var sub Subscription
+	sub, err := b.Subscribe(ctx, func(msg *pb.ChatMessage) {
+		if msg.MessageId != "first" {
+			return
+		}
+		sub.Unsubscribe()
+		b.Publish(ctx, &pb.ChatMessage{MessageId: "second"})
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Publish(ctx, &pb.ChatMessage{MessageId: "first"})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish deadlocked")
+	}
+}
+
+func TestMemoryBrokerClosed(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx := context.Background()
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := b.Publish(ctx, &pb.ChatMessage{}); !errors.Is(err, ErrBrokerClosed) {
+		t.Fatalf("Publish after Close = %v, want ErrBrokerClosed", err)
+	}
+	if _, err := b.Subscribe(ctx, func(*pb.ChatMessage) {}); !errors.Is(err, ErrBrokerClosed) {
+		t.Fatalf("Subscribe after Close = %v, want ErrBrokerClosed", err)
+	}
+}
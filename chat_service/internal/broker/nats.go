@@ -0,0 +1,83 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "chat.service/api/proto"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+)
+
+const natsSubjectPrefix = "chat.messages."
+
+// NatsBroker shares live messages between replicas over NATS core
+// pub/sub. Messages are published on "chat.messages.<chat_id>" as
+// protobuf-encoded ChatMessage.
+type NatsBroker struct {
+	conn *nats.Conn
+}
+
+func NewNatsBroker(url string, opts ...nats.Option) (*NatsBroker, error) {
+	op := "broker.NewNatsBroker"
+
+	conn, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &NatsBroker{conn: conn}, nil
+}
+
+func (b *NatsBroker) Publish(ctx context.Context, msg *pb.ChatMessage) error {
+	op := "broker.NatsBroker.Publish"
+
+	if b.conn.IsClosed() {
+		return ErrBrokerClosed
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := b.conn.Publish(natsSubjectPrefix+msg.ChatId, data); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (b *NatsBroker) Subscribe(ctx context.Context, handler Handler) (Subscription, error) {
+	op := "broker.NatsBroker.Subscribe"
+
+	if b.conn.IsClosed() {
+		return nil, ErrBrokerClosed
+	}
+
+	sub, err := b.conn.Subscribe(natsSubjectPrefix+">", func(m *nats.Msg) {
+		msg := new(pb.ChatMessage)
+		if err := proto.Unmarshal(m.Data, msg); err != nil {
+			log.Printf("%s: failed to decode message on %s: %v", op, m.Subject, err)
+			return
+		}
+
+		handler(msg)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sub, nil
+}
+
+func (b *NatsBroker) Close() error {
+	op := "broker.NatsBroker.Close"
+
+	if err := b.conn.Drain(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
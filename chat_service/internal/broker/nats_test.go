@@ -0,0 +1,106 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "chat.service/api/proto"
+	"github.com/nats-io/nats-server/v2/server"
+	"google.golang.org/protobuf/proto"
+)
+
+// runNatsServer starts an embedded NATS server on a random port.
+func runNatsServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:   "127.0.0.1",
+		Port:   server.RANDOM_PORT,
+		NoLog:  true,
+		NoSigs: true,
+	})
+	if err != nil {
+		t.Fatalf("start nats server: %v", err)
+	}
+
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+func TestNatsBrokerFansOutAcrossReplicas(t *testing.T) {
+	srv := runNatsServer(t)
+	ctx := context.Background()
+
+	replicas := make([]*NatsBroker, 2)
+	received := make([]chan *pb.ChatMessage, 2)
+	for i := range replicas {
+		b, err := NewNatsBroker(srv.ClientURL())
+		if err != nil {
+			t.Fatalf("NewNatsBroker: %v", err)
+		}
+		t.Cleanup(func() { b.Close() })
+		replicas[i] = b
+
+		ch := make(chan *pb.ChatMessage, 1)
+		received[i] = ch
+		if _, err := b.Subscribe(ctx, func(msg *pb.ChatMessage) { ch <- msg }); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+		if err := b.conn.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+	}
+
+	want := &pb.ChatMessage{ChatId: "chat-1", MessageId: "m1", Text: "hello"}
+	if err := replicas[0].Publish(ctx, want); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	for i, ch := range received {
+		select {
+		case got := <-ch:
+			if !proto.Equal(got, want) {
+				t.Fatalf("replica %d got %v, want %v", i, got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("replica %d received nothing", i)
+		}
+	}
+}
+
+func TestNatsBrokerUnsubscribe(t *testing.T) {
+	srv := runNatsServer(t)
+	ctx := context.Background()
+
+	b, err := NewNatsBroker(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("NewNatsBroker: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+
+	got := make(chan *pb.ChatMessage, 1)
+	sub, err := b.Subscribe(ctx, func(msg *pb.ChatMessage) { got <- msg })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	if err := b.Publish(ctx, &pb.ChatMessage{ChatId: "chat-1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	b.conn.Flush()
+
+	select {
+	case msg := <-got:
+		t.Fatalf("received %v after Unsubscribe", msg)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,122 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Result is what SendMessage returned for the first delivery of a
+// client_message_id; retries get it back unchanged.
+type Result struct {
+	MessageID string
+	Timestamp time.Time
+}
+
+type key struct {
+	userID          string
+	clientMessageID string
+}
+
+type entry struct {
+	result    Result
+	expiresAt time.Time
+}
+
+// Store remembers SendMessage results by (user_id, client_message_id)
+// for a fixed window so CLI retries after network errors do not create
+// duplicate messages.
+type Store struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[key]entry
+	now     func() time.Time
+}
+
+func NewStore(window time.Duration) *Store {
+	return &Store{
+		window:  window,
+		entries: make(map[key]entry),
+		now:     time.Now,
+	}
+}
+
+// Lookup returns the stored result for a retry, if the original send
+// happened within the window.
+func (s *Store) Lookup(userID, clientMessageID string) (Result, bool) {
+	if clientMessageID == "" {
+		return Result{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := key{userID: userID, clientMessageID: clientMessageID}
+	e, ok := s.entries[k]
+	if !ok {
+		return Result{}, false
+	}
+	if s.now().After(e.expiresAt) {
+		delete(s.entries, k)
+		return Result{}, false
+	}
+
+	return e.result, true
+}
+
+// Remember records the result of a send. If another request with the
+// same key got there first, its result wins and is returned with
+// stored set to false, so the caller can discard its own message.
+func (s *Store) Remember(
+	userID, clientMessageID string,
+	result Result,
+) (Result, bool) {
+	if clientMessageID == "" {
+		return result, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	k := key{userID: userID, clientMessageID: clientMessageID}
+	if e, ok := s.entries[k]; ok && !now.After(e.expiresAt) {
+		return e.result, false
+	}
+
+	s.entries[k] = entry{
+		result:    result,
+		expiresAt: now.Add(s.window),
+	}
+
+	return result, true
+}
+
+// Cleanup drops expired entries. It is meant to run periodically, see
+// Run.
+func (s *Store) Cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// Run calls Cleanup every interval until ctx is done.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Cleanup()
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestStore(window time.Duration) (*Store, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := NewStore(window)
+	s.now = clock.Now
+	return s, clock
+}
+
+func TestRetryGetsOriginalResult(t *testing.T) {
+	s, _ := newTestStore(time.Minute)
+	first := Result{MessageID: "m1", Timestamp: time.Unix(1, 0)}
+
+	if _, stored := s.Remember("alice", "c1", first); !stored {
+		t.Fatal("first Remember was not stored")
+	}
+
+	got, ok := s.Lookup("alice", "c1")
+	if !ok || got != first {
+		t.Fatalf("Lookup = %v, %v, want %v", got, ok, first)
+	}
+}
+
+func TestConcurrentSendLosesToFirst(t *testing.T) {
+	s, _ := newTestStore(time.Minute)
+	first := Result{MessageID: "m1"}
+
+	s.Remember("alice", "c1", first)
+	got, stored := s.Remember("alice", "c1", Result{MessageID: "m2"})
+	if stored || got != first {
+		t.Fatalf("second Remember = %v, %v, want %v, false", got, stored, first)
+	}
+}
+
+func TestKeysAreScopedPerUser(t *testing.T) {
+	s, _ := newTestStore(time.Minute)
+
+	s.Remember("alice", "c1", Result{MessageID: "m1"})
+	if _, ok := s.Lookup("bob", "c1"); ok {
+		t.Fatal("bob saw alice's client_message_id")
+	}
+}
+
+func TestEmptyClientMessageIDIsNotDeduplicated(t *testing.T) {
+	s, _ := newTestStore(time.Minute)
+
+	s.Remember("alice", "", Result{MessageID: "m1"})
+	if _, ok := s.Lookup("alice", ""); ok {
+		t.Fatal("empty client_message_id was remembered")
+	}
+	if _, stored := s.Remember("alice", "", Result{MessageID: "m2"}); !stored {
+		t.Fatal("second send without client_message_id was rejected")
+	}
+}
+
+func TestEntriesExpireAfterWindow(t *testing.T) {
+	s, clock := newTestStore(time.Minute)
+
+	s.Remember("alice", "c1", Result{MessageID: "m1"})
+	clock.now = clock.now.Add(time.Minute + time.Second)
+
+	if _, ok := s.Lookup("alice", "c1"); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if _, stored := s.Remember("alice", "c1", Result{MessageID: "m2"}); !stored {
+		t.Fatal("send after the window was treated as a retry")
+	}
+}
+
+func TestCleanupDropsExpiredEntries(t *testing.T) {
+	s, clock := newTestStore(time.Minute)
+
+	s.Remember("alice", "old", Result{MessageID: "m1"})
+	clock.now = clock.now.Add(2 * time.Minute)
+	s.Remember("alice", "new", Result{MessageID: "m2"})
+
+	s.Cleanup()
+
+	if len(s.entries) != 1 {
+		t.Fatalf("%d entries after Cleanup, want 1", len(s.entries))
+	}
+	if _, ok := s.Lookup("alice", "new"); !ok {
+		t.Fatal("Cleanup dropped an unexpired entry")
+	}
+}
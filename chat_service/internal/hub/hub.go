@@ -0,0 +1,330 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+
+	pb "chat.service/api/proto"
+)
+
+var (
+	ErrSubscriberTooSlow = errors.New("subscriber is too slow")
+	ErrLagging           = errors.New("subscriber is lagging, refetch history")
+	ErrHubClosed         = errors.New("hub is closed")
+	ErrUnsubscribed      = errors.New("subscriber is unsubscribed")
+)
+
+// OverflowPolicy decides what happens when a subscriber's queue is full.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the oldest queued message to make room.
+	DropOldest OverflowPolicy = iota
+	// Disconnect closes the subscription with ErrSubscriberTooSlow.
+	Disconnect
+	// MarkLagging clears the queue and reports ErrLagging once, so the
+	// client knows to refetch the gap from history.
+	MarkLagging
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop_oldest"
+	case Disconnect:
+		return "disconnect"
+	case MarkLagging:
+		return "mark_lagging"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+func ParseOverflowPolicy(value string) (OverflowPolicy, error) {
+	switch value {
+	case "drop_oldest":
+		return DropOldest, nil
+	case "disconnect":
+		return Disconnect, nil
+	case "mark_lagging":
+		return MarkLagging, nil
+	default:
+		return 0, fmt.Errorf("unknown overflow policy: %s", value)
+	}
+}
+
+type Config struct {
+	QueueSize int
+	Policy    OverflowPolicy
+}
+
+func DefaultConfig() Config {
+	return Config{
+		QueueSize: 256,
+		Policy:    DropOldest,
+	}
+}
+
+// Exported through expvar under "chat_hub".
+var metrics = expvar.NewMap("chat_hub")
+
+const (
+	metricSubscribers  = "subscribers"
+	metricQueueDepth   = "queue_depth"
+	metricDropped      = "dropped_messages"
+	metricDisconnected = "disconnected_subscribers"
+	metricLagged       = "lagged_subscribers"
+)
+
+// Hub fans out chat messages to ConnectChat subscribers. Publishing
+// never blocks: every subscriber has its own bounded queue, and a full
+// queue is handled according to the configured OverflowPolicy.
+type Hub struct {
+	mu     sync.RWMutex
+	cfg    Config
+	chats  map[string]map[*Subscriber]struct{}
+	closed bool
+}
+
+func NewHub(cfg Config) *Hub {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultConfig().QueueSize
+	}
+
+	return &Hub{
+		cfg:   cfg,
+		chats: make(map[string]map[*Subscriber]struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(chatID string) (*Subscriber, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+
+	sub := &Subscriber{
+		chatID: chatID,
+		size:   h.cfg.QueueSize,
+		policy: h.cfg.Policy,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	subs, ok := h.chats[chatID]
+	if !ok {
+		subs = make(map[*Subscriber]struct{})
+		h.chats[chatID] = subs
+	}
+	subs[sub] = struct{}{}
+	metrics.Add(metricSubscribers, 1)
+
+	return sub, nil
+}
+
+// Unsubscribe ends the subscription. It is safe to call after the hub
+// already dropped a too slow subscriber.
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.remove(sub)
+	sub.close(ErrUnsubscribed)
+}
+
+// Publish enqueues msg for every subscriber of its chat. Subscribers
+// disconnected by the Disconnect policy are removed from the hub right
+// away.
+func (h *Hub) Publish(msg *pb.ChatMessage) {
+	var disconnected []*Subscriber
+
+	h.mu.RLock()
+	for sub := range h.chats[msg.ChatId] {
+		if sub.enqueue(msg) {
+			disconnected = append(disconnected, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range disconnected {
+		h.remove(sub)
+	}
+}
+
+func (h *Hub) remove(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.chats[sub.chatID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; ok {
+		delete(subs, sub)
+		metrics.Add(metricSubscribers, -1)
+	}
+	if len(subs) == 0 {
+		delete(h.chats, sub.chatID)
+	}
+}
+
+// Close terminates every subscription with ErrHubClosed.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for chatID, subs := range h.chats {
+		for sub := range subs {
+			sub.close(ErrHubClosed)
+			metrics.Add(metricSubscribers, -1)
+		}
+		delete(h.chats, chatID)
+	}
+}
+
+type SubscriberStats struct {
+	ChatID     string
+	QueueDepth int
+	Lagging    bool
+}
+
+func (h *Hub) Stats() []SubscriberStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := make([]SubscriberStats, 0)
+	for chatID, subs := range h.chats {
+		for sub := range subs {
+			sub.mu.Lock()
+			stats = append(stats, SubscriberStats{
+				ChatID:     chatID,
+				QueueDepth: len(sub.queue),
+				Lagging:    sub.lagging,
+			})
+			sub.mu.Unlock()
+		}
+	}
+
+	return stats
+}
+
+type Subscriber struct {
+	chatID string
+	size   int
+	policy OverflowPolicy
+
+	mu      sync.Mutex
+	queue   []*pb.ChatMessage
+	lagging bool
+	err     error
+	closed  bool
+
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func (s *Subscriber) ChatID() string {
+	return s.chatID
+}
+
+// Receive blocks until a message is available, the subscription ends
+// or ctx is done. Under MarkLagging it returns ErrLagging once after an
+// overflow and then continues with newer messages.
+func (s *Subscriber) Receive(ctx context.Context) (*pb.ChatMessage, error) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			err := s.err
+			s.mu.Unlock()
+			return nil, err
+		}
+		if s.lagging {
+			s.lagging = false
+			s.mu.Unlock()
+			return nil, ErrLagging
+		}
+		if len(s.queue) > 0 {
+			msg := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			metrics.Add(metricQueueDepth, -1)
+			return msg, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.done:
+		case <-s.notify:
+		}
+	}
+}
+
+// enqueue reports whether the subscriber was disconnected because its
+// queue was full.
+func (s *Subscriber) enqueue(msg *pb.ChatMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	if len(s.queue) >= s.size {
+		switch s.policy {
+		case DropOldest:
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			metrics.Add(metricQueueDepth, -1)
+			metrics.Add(metricDropped, 1)
+		case Disconnect:
+			metrics.Add(metricDisconnected, 1)
+			s.closeLocked(ErrSubscriberTooSlow)
+			return true
+		case MarkLagging:
+			metrics.Add(metricQueueDepth, -int64(len(s.queue)))
+			metrics.Add(metricDropped, int64(len(s.queue)))
+			metrics.Add(metricLagged, 1)
+			s.queue = nil
+			s.lagging = true
+			s.wake()
+			return false
+		}
+	}
+
+	s.queue = append(s.queue, msg)
+	metrics.Add(metricQueueDepth, 1)
+	s.wake()
+	return false
+}
+
+func (s *Subscriber) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closeLocked(err)
+}
+
+func (s *Subscriber) closeLocked(err error) {
+	if s.closed {
+		return
+	}
+
+	metrics.Add(metricQueueDepth, -int64(len(s.queue)))
+	s.queue = nil
+	s.closed = true
+	s.err = err
+	close(s.done)
+}
+
+func (s *Subscriber) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,166 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	pb "chat.service/api/proto"
+)
+
+func publish(h *Hub, chatID string, ids ...string) {
+	for _, id := range ids {
+		h.Publish(&pb.ChatMessage{ChatId: chatID, MessageId: id})
+	}
+}
+
+func receive(t *testing.T, sub *Subscriber) (*pb.ChatMessage, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return sub.Receive(ctx)
+}
+
+func metricValue(name string) int64 {
+	if v, ok := metrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestDropOldestKeepsNewestMessages(t *testing.T) {
+	h := NewHub(Config{QueueSize: 2, Policy: DropOldest})
+	sub, err := h.Subscribe("chat")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer h.Unsubscribe(sub)
+
+	publish(h, "chat", "m1", "m2", "m3")
+
+	for _, want := range []string{"m2", "m3"} {
+		msg, err := receive(t, sub)
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		if msg.MessageId != want {
+			t.Fatalf("got %s, want %s", msg.MessageId, want)
+		}
+	}
+}
+
+func TestDisconnectRemovesSlowSubscriber(t *testing.T) {
+	h := NewHub(Config{QueueSize: 1, Policy: Disconnect})
+	subscribers := metricValue(metricSubscribers)
+
+	slow, err := h.Subscribe("chat")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	fast, err := h.Subscribe("chat")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer h.Unsubscribe(fast)
+
+	publish(h, "chat", "m1")
+	if _, err := receive(t, fast); err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	publish(h, "chat", "m2")
+
+	if _, err := receive(t, slow); !errors.Is(err, ErrSubscriberTooSlow) {
+		t.Fatalf("Receive on slow subscriber = %v, want ErrSubscriberTooSlow", err)
+	}
+	if msg, err := receive(t, fast); err != nil || msg.MessageId != "m2" {
+		t.Fatalf("fast subscriber got %v, %v, want m2", msg, err)
+	}
+
+	if stats := h.Stats(); len(stats) != 1 {
+		t.Fatalf("hub has %d subscribers, want 1", len(stats))
+	}
+	if got := metricValue(metricSubscribers) - subscribers; got != 1 {
+		t.Fatalf("subscribers metric grew by %d, want 1", got)
+	}
+
+	// Unsubscribing a dropped subscriber must not count it twice.
+	h.Unsubscribe(slow)
+	if got := metricValue(metricSubscribers) - subscribers; got != 1 {
+		t.Fatalf("subscribers metric grew by %d after Unsubscribe, want 1", got)
+	}
+}
+
+func TestMarkLaggingReportsGapOnce(t *testing.T) {
+	h := NewHub(Config{QueueSize: 2, Policy: MarkLagging})
+	sub, err := h.Subscribe("chat")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer h.Unsubscribe(sub)
+
+	publish(h, "chat", "m1", "m2", "m3")
+
+	if _, err := receive(t, sub); !errors.Is(err, ErrLagging) {
+		t.Fatalf("Receive = %v, want ErrLagging", err)
+	}
+
+	publish(h, "chat", "m4")
+	msg, err := receive(t, sub)
+	if err != nil {
+		t.Fatalf("Receive after lagging: %v", err)
+	}
+	if msg.MessageId != "m4" {
+		t.Fatalf("got %s, want m4", msg.MessageId)
+	}
+}
+
+func TestPublishOnlyReachesSubscribersOfTheChat(t *testing.T) {
+	h := NewHub(DefaultConfig())
+	sub, err := h.Subscribe("other")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer h.Unsubscribe(sub)
+
+	publish(h, "chat", "m1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if msg, err := sub.Receive(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Receive = %v, %v, want no message", msg, err)
+	}
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	h := NewHub(DefaultConfig())
+	sub, err := h.Subscribe("chat")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	h.Close()
+
+	if _, err := receive(t, sub); !errors.Is(err, ErrHubClosed) {
+		t.Fatalf("Receive after Close = %v, want ErrHubClosed", err)
+	}
+	if _, err := h.Subscribe("chat"); !errors.Is(err, ErrHubClosed) {
+		t.Fatalf("Subscribe after Close = %v, want ErrHubClosed", err)
+	}
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for _, policy := range []OverflowPolicy{DropOldest, Disconnect, MarkLagging} {
+		got, err := ParseOverflowPolicy(policy.String())
+		if err != nil || got != policy {
+			t.Fatalf("ParseOverflowPolicy(%q) = %v, %v", policy, got, err)
+		}
+	}
+
+	if _, err := ParseOverflowPolicy("block"); err == nil {
+		t.Fatal("ParseOverflowPolicy accepted an unknown policy")
+	}
+}
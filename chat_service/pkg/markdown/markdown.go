@@ -0,0 +1,355 @@
+package markdown
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const MaxMessageLength = 4096
+
+var (
+	ErrEmptyMessage   = errors.New("message is empty")
+	ErrMessageTooLong = errors.New("message is too long")
+	ErrInvalidUTF8    = errors.New("message is not valid UTF-8")
+	ErrControlChar    = errors.New("message contains control characters")
+)
+
+type NodeKind int
+
+const (
+	Text NodeKind = iota
+	Bold
+	Italic
+	Code
+	CodeBlock
+	Link
+	LineBreak
+)
+
+// Node is one element of a parsed message. Bold, Italic and Link keep
+// their content in Children; Text, Code and CodeBlock in Text.
+type Node struct {
+	Kind     NodeKind
+	Text     string
+	URL      string
+	Children []Node
+}
+
+// Validate checks the limits shared by every message format. Errors
+// are meant to be reported as codes.InvalidArgument.
+func Validate(text string) error {
+	if !utf8.ValidString(text) {
+		return ErrInvalidUTF8
+	}
+	if strings.TrimSpace(text) == "" {
+		return ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(text) > MaxMessageLength {
+		return ErrMessageTooLong
+	}
+	if hasControlChars(text) {
+		return ErrControlChar
+	}
+
+	return nil
+}
+
+// hasControlChars reports C0 and C1 control characters other than tab
+// and line breaks. They include ESC, which would let a sender inject
+// terminal escape sequences into every client rendering the message.
+func hasControlChars(text string) bool {
+	for i, r := range text {
+		switch {
+		case r == '\n' || r == '\t':
+		case r == '\r' && strings.HasPrefix(text[i+1:], "\n"):
+		case unicode.IsControl(r):
+			return true
+		}
+	}
+
+	return false
+}
+
+// stripControlChars removes the characters hasControlChars rejects,
+// including a carriage return of a CRLF line break.
+func stripControlChars(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// Sanitize validates a markdown message and returns it rewritten to
+// the supported subset: bold, italic, code spans, fenced code blocks
+// and http(s)/mailto links. Anything else, such as headings, quotes,
+// images and HTML, is stripped down to its text.
+func Sanitize(text string) (string, error) {
+	if err := Validate(text); err != nil {
+		return "", err
+	}
+
+	out := Render(Parse(text))
+	if strings.TrimSpace(out) == "" {
+		return "", ErrEmptyMessage
+	}
+
+	return out, nil
+}
+
+func Parse(text string) []Node {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	var nodes []Node
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			var block []string
+			for i++; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) == "```" {
+					break
+				}
+				block = append(block, lines[i])
+			}
+			if len(nodes) > 0 && nodes[len(nodes)-1].Kind != LineBreak {
+				nodes = append(nodes, Node{Kind: LineBreak})
+			}
+			nodes = append(nodes, Node{
+				Kind: CodeBlock,
+				Text: strings.Join(block, "\n"),
+			})
+			if i+1 < len(lines) {
+				nodes = append(nodes, Node{Kind: LineBreak})
+			}
+			continue
+		}
+
+		nodes = append(nodes, parseInline(stripBlockMarkers(line))...)
+		if i+1 < len(lines) {
+			nodes = append(nodes, Node{Kind: LineBreak})
+		}
+	}
+
+	return mergeText(nodes)
+}
+
+// stripBlockMarkers removes heading and quote markers, which are not
+// part of the supported subset.
+func stripBlockMarkers(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	for strings.HasPrefix(trimmed, ">") {
+		trimmed = strings.TrimLeft(trimmed[1:], " ")
+	}
+
+	hashes := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if hashes > 0 && hashes <= 6 &&
+		(len(trimmed) == hashes || trimmed[hashes] == ' ') {
+		trimmed = strings.TrimLeft(trimmed[hashes:], " ")
+	}
+
+	if trimmed == strings.TrimLeft(line, " ") {
+		return line
+	}
+	return trimmed
+}
+
+func parseInline(s string) []Node {
+	var nodes []Node
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, Node{Kind: Text, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				flush()
+				nodes = append(nodes, Node{Kind: Code, Text: s[i+1 : i+1+end]})
+				i += end + 2
+				continue
+			}
+
+		case (c == '*' || c == '_') && i+1 < len(s) && s[i+1] == c:
+			delim := s[i : i+2]
+			if end := strings.Index(s[i+2:], delim); end > 0 {
+				flush()
+				nodes = append(nodes, Node{
+					Kind:     Bold,
+					Children: parseInline(s[i+2 : i+2+end]),
+				})
+				i += end + 4
+				continue
+			}
+
+		case (c == '*' || c == '_') && canOpenEmphasis(s, i):
+			if end := findEmphasisClose(s, i+1, c); end > i+1 {
+				flush()
+				nodes = append(nodes, Node{
+					Kind:     Italic,
+					Children: parseInline(s[i+1 : end]),
+				})
+				i = end + 1
+				continue
+			}
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			// Images are not supported; keep the alt text or link.
+			i++
+			continue
+
+		case c == '[':
+			if label, target, n, ok := parseLink(s[i:]); ok {
+				flush()
+				if safeURL(target) {
+					nodes = append(nodes, Node{
+						Kind:     Link,
+						URL:      target,
+						Children: parseInline(label),
+					})
+				} else {
+					nodes = append(nodes, parseInline(label)...)
+				}
+				i += n
+				continue
+			}
+
+		case c == '<':
+			if end := htmlTagEnd(s[i:]); end > 0 {
+				i += end
+				continue
+			}
+		}
+
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+
+	return nodes
+}
+
+func canOpenEmphasis(s string, i int) bool {
+	if i+1 >= len(s) || s[i+1] == ' ' {
+		return false
+	}
+	if s[i] == '_' && i > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func findEmphasisClose(s string, from int, delim byte) int {
+	for j := from; j < len(s); j++ {
+		if s[j] != delim || s[j-1] == ' ' {
+			continue
+		}
+		if j+1 < len(s) && s[j+1] == delim {
+			j++
+			continue
+		}
+		if delim == '_' && j+1 < len(s) {
+			r, _ := utf8.DecodeRuneInString(s[j+1:])
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				continue
+			}
+		}
+		return j
+	}
+	return -1
+}
+
+// parseLink parses "[label](target)" at the start of s and returns the
+// number of bytes consumed.
+func parseLink(s string) (label, target string, n int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if closeLabel < 1 || strings.ContainsAny(s[1:closeLabel], "[]\n") {
+		return "", "", 0, false
+	}
+
+	rest := s[closeLabel+2:]
+	closeTarget := strings.IndexByte(rest, ')')
+	if closeTarget < 0 {
+		return "", "", 0, false
+	}
+
+	target = strings.TrimSpace(rest[:closeTarget])
+	if target == "" || strings.ContainsAny(target, " \n") {
+		return "", "", 0, false
+	}
+
+	return s[1:closeLabel], target, closeLabel + 2 + closeTarget + 1, true
+}
+
+func safeURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	default:
+		return false
+	}
+}
+
+func htmlTagEnd(s string) int {
+	if len(s) < 3 {
+		return -1
+	}
+
+	c := s[1]
+	if !(c == '/' || c == '!' || c == '?' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+		return -1
+	}
+
+	end := strings.IndexByte(s, '>')
+	if end < 0 || strings.ContainsAny(s[1:end], "<\n") {
+		return -1
+	}
+
+	return end + 1
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("\\`*_[]()<>#!", c) >= 0
+}
+
+func mergeText(nodes []Node) []Node {
+	merged := nodes[:0]
+	for _, node := range nodes {
+		last := len(merged) - 1
+		if node.Kind == Text && last >= 0 && merged[last].Kind == Text {
+			merged[last].Text += node.Text
+			continue
+		}
+		merged = append(merged, node)
+	}
+
+	return merged
+}
@@ -0,0 +1,121 @@
+package markdown
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want error
+	}{
+		{"plain", "hello", nil},
+		{"tabs and newlines", "a\tb\nc", nil},
+		{"crlf", "a\r\nb", nil},
+		{"empty", "  \n ", ErrEmptyMessage},
+		{"too long", strings.Repeat("a", MaxMessageLength+1), ErrMessageTooLong},
+		{"invalid utf-8", "\xff", ErrInvalidUTF8},
+		{"escape", "hi \x1b[2J", ErrControlChar},
+		{"osc 8 link", "\x1b]8;;https://evil\x07click\x1b]8;;\x07", ErrControlChar},
+		{"bare carriage return", "safe\rspoofed", ErrControlChar},
+		{"c1 csi", "hi \u009b2J", ErrControlChar},
+		{"nul", "a\x00b", ErrControlChar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.text); !errors.Is(err, tt.want) {
+				t.Fatalf("Validate(%q) = %v, want %v", tt.text, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"supported subset", "**bold** *it* `code`", "**bold** *it* `code`"},
+		{"heading stripped", "# Title", "Title"},
+		{"quote stripped", "> quoted", "quoted"},
+		{"html stripped", "a <script>x</script> b", "a x b"},
+		{"safe link kept", "[site](https://example.com)", "[site](https://example.com)"},
+		{"javascript link dropped", "[click](javascript:void)", "click"},
+		{"image keeps alt text", "![alt](https://example.com/a.png)", "[alt](https://example.com/a.png)"},
+		{"code block", "```\nx := 1\n```", "```\nx := 1\n```"},
+		{"escaped punctuation", `\*not italic\*`, `\*not italic\*`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sanitize(tt.text)
+			if err != nil {
+				t.Fatalf("Sanitize(%q): %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Sanitize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeIsIdempotent(t *testing.T) {
+	inputs := []string{
+		"**a *b* c**",
+		"snake_case_name and _it_",
+		"[x](https://example.com/a_(b)) tail",
+		"line1\n```\ncode\n```\nline2",
+	}
+
+	for _, input := range inputs {
+		once, err := Sanitize(input)
+		if err != nil {
+			t.Fatalf("Sanitize(%q): %v", input, err)
+		}
+		twice, err := Sanitize(once)
+		if err != nil {
+			t.Fatalf("Sanitize(%q): %v", once, err)
+		}
+		if once != twice {
+			t.Fatalf("Sanitize is not idempotent: %q -> %q -> %q", input, once, twice)
+		}
+	}
+}
+
+func TestSanitizeRejectsMessagesThatAreOnlyMarkup(t *testing.T) {
+	if _, err := Sanitize("<b></b>"); !errors.Is(err, ErrEmptyMessage) {
+		t.Fatalf("Sanitize = %v, want ErrEmptyMessage", err)
+	}
+}
+
+func TestRenderANSIStripsControlCharacters(t *testing.T) {
+	inputs := []string{
+		"hi \x1b]0;pwned\x07",
+		"`\x1b[2J`",
+		"```\n\x1b[1A\n```",
+		"[x](https://example.com/\x1b[2J)",
+		"a\u009b31mb",
+	}
+
+	for _, input := range inputs {
+		out := RenderANSI(input)
+		for _, seq := range []string{"\x1b]", "\x1b[2J", "\x1b[1A", "\x07", "\u009b"} {
+			if strings.Contains(out, seq) {
+				t.Fatalf("RenderANSI(%q) = %q, contains %q", input, out, seq)
+			}
+		}
+	}
+}
+
+func TestRenderANSIStyles(t *testing.T) {
+	got := RenderANSI("**b** `c`")
+	want := ansiBold + "b" + ansiReset + " " + ansiCode + "c" + ansiReset
+	if got != want {
+		t.Fatalf("RenderANSI = %q, want %q", got, want)
+	}
+}
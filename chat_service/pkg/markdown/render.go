@@ -0,0 +1,107 @@
+package markdown
+
+import "strings"
+
+// Render writes nodes back as markdown, escaping text so that the
+// result parses to the same nodes.
+func Render(nodes []Node) string {
+	var b strings.Builder
+	render(&b, nodes)
+	return b.String()
+}
+
+func render(b *strings.Builder, nodes []Node) {
+	for _, node := range nodes {
+		switch node.Kind {
+		case Text:
+			b.WriteString(escape(node.Text))
+		case Bold:
+			b.WriteString("**")
+			render(b, node.Children)
+			b.WriteString("**")
+		case Italic:
+			b.WriteString("*")
+			render(b, node.Children)
+			b.WriteString("*")
+		case Code:
+			b.WriteString("`" + node.Text + "`")
+		case CodeBlock:
+			b.WriteString("```\n" + node.Text + "\n```")
+		case Link:
+			b.WriteString("[")
+			render(b, node.Children)
+			b.WriteString("](" + node.URL + ")")
+		case LineBreak:
+			b.WriteString("\n")
+		}
+	}
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if isPunct(s[i]) {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiCode      = "\x1b[36m"
+	ansiFaint     = "\x1b[2m"
+)
+
+// RenderANSI formats a markdown message for a terminal. Control
+// characters are stripped even though Validate already rejects them, so
+// only the escape sequences added here reach the terminal.
+func RenderANSI(text string) string {
+	var b strings.Builder
+	renderANSI(&b, Parse(stripControlChars(text)), "")
+	return b.String()
+}
+
+// renderANSI writes nodes with the styles inherited from their parents
+// in active, restoring them after every nested reset.
+func renderANSI(b *strings.Builder, nodes []Node, active string) {
+	styled := func(style, content string) {
+		b.WriteString(style + content + ansiReset + active)
+	}
+
+	for _, node := range nodes {
+		switch node.Kind {
+		case Text:
+			b.WriteString(node.Text)
+		case Bold:
+			b.WriteString(ansiBold)
+			renderANSI(b, node.Children, active+ansiBold)
+			b.WriteString(ansiReset + active)
+		case Italic:
+			b.WriteString(ansiItalic)
+			renderANSI(b, node.Children, active+ansiItalic)
+			b.WriteString(ansiReset + active)
+		case Code:
+			styled(ansiCode, node.Text)
+		case CodeBlock:
+			for i, line := range strings.Split(node.Text, "\n") {
+				if i > 0 {
+					b.WriteString("\n")
+				}
+				styled(ansiCode, "  "+line)
+			}
+		case Link:
+			b.WriteString(ansiUnderline)
+			renderANSI(b, node.Children, active+ansiUnderline)
+			b.WriteString(ansiReset + active)
+			styled(ansiFaint, " ("+node.URL+")")
+		case LineBreak:
+			b.WriteString("\n")
+		}
+	}
+}